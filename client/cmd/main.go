@@ -28,9 +28,11 @@ func main() {
 	httpClient := &http.Client{}
 	translClient := clients.NewLibraryClient(conf, httpClient, logger)
 	backup := repositories.NewBackUpCopyRepo(logger)
+	cache := repositories.NewCacheRepo(logger)
+	outbox := repositories.NewOutboxRepo(logger)
 	userClient := clients.NewUserClient(conf, httpClient, logger)
 
-	comp := competition.NewCompetition(translClient, userClient, backup, logger)
+	comp := competition.NewCompetition(translClient, userClient, backup, cache, outbox, logger)
 
 	ctx := context.Background()
 	logger.Info("Start competition")
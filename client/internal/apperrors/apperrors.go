@@ -1,10 +1,18 @@
 package apperrors
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
 
+// AppError wraps its cause in Err, HTTPCode is the status the server answered with.
 type AppError struct {
-	Message string
-	Code    string
+	Message  string
+	Code     string
+	HTTPCode int
+	Err      error
+	origin   *AppError
 }
 
 func NewAppError() *AppError {
@@ -12,6 +20,48 @@ func NewAppError() *AppError {
 }
 
 var (
+	// The server answers, a caller tells them apart with errors.Is(err, &apperrors.UnauthorizedErr).
+	BadRequestErr = AppError{
+		Message:  "Failed to BadRequestErr",
+		Code:     badRequest,
+		HTTPCode: http.StatusBadRequest,
+	}
+	UnauthorizedErr = AppError{
+		Message:  "Failed to UnauthorizedErr, login again",
+		Code:     unauthorized,
+		HTTPCode: http.StatusUnauthorized,
+	}
+	NotFoundErr = AppError{
+		Message:  "Failed to NotFoundErr",
+		Code:     notFound,
+		HTTPCode: http.StatusNotFound,
+	}
+	ConflictErr = AppError{
+		Message:  "Failed to ConflictErr",
+		Code:     conflict,
+		HTTPCode: http.StatusConflict,
+	}
+	// InProgressErr is a retry the server got while it still runs the first request with the same Idempotency-Key.
+	InProgressErr = AppError{
+		Message:  "Failed to InProgressErr, the first request is still running",
+		Code:     idempotencyKeyInProgress,
+		HTTPCode: http.StatusConflict,
+	}
+	// TooManyRequestsErr is a request the server has limited, it takes it again after Retry-After.
+	TooManyRequestsErr = AppError{
+		Message:  "Failed to TooManyRequestsErr, try again later",
+		Code:     tooManyRequests,
+		HTTPCode: http.StatusTooManyRequests,
+	}
+	// UnreachableErr is a request which never got an answer, the client goes on offline.
+	UnreachableErr = AppError{
+		Message: "Failed to reach the server",
+		Code:    unreachable,
+	}
+	ResponseErr = AppError{
+		Message: "Failed to ResponseErr",
+		Code:    response,
+	}
 	SetupDatabaseErr = AppError{
 		Message: "Failed SetupDatabaseErr",
 		Code:    envInit,
@@ -41,6 +91,22 @@ var (
 		Message: "Failed to SaveUserErr",
 		Code:    backUpRepo,
 	}
+	GetCacheErr = AppError{
+		Message: "Failed to GetCacheErr",
+		Code:    backUpRepo,
+	}
+	SaveCacheErr = AppError{
+		Message: "Failed to SaveCacheErr",
+		Code:    backUpRepo,
+	}
+	GetOutboxErr = AppError{
+		Message: "Failed to GetOutboxErr",
+		Code:    backUpRepo,
+	}
+	SaveOutboxErr = AppError{
+		Message: "Failed to SaveOutboxErr",
+		Code:    backUpRepo,
+	}
 	GetAllWordsLibErr = AppError{
 		Message: "Failed to GetAllWordsLibErr",
 		Code:    repoLibrary,
@@ -93,18 +159,62 @@ var (
 		Message: "Failed to GetUserWithLearnByIDLimitErr",
 		Code:    clientUser,
 	}
+	GetUserWithLearnedByIDLimitErr = AppError{
+		Message: "Failed to GetUserWithLearnedByIDLimitErr",
+		Code:    clientUser,
+	}
+	ApplyWordOperationsErr = AppError{
+		Message: "Failed to ApplyWordOperationsErr",
+		Code:    clientUser,
+	}
 	MoveWordToLearnedErr = AppError{
 		Message: "Failed to MoveWordToLearnedErr",
 		Code:    clientUser,
 	}
+	RefreshTokenErr = AppError{
+		Message: "Failed to RefreshTokenErr",
+		Code:    clientUser,
+	}
+	StartQuizSessionErr = AppError{
+		Message: "Failed to StartQuizSessionErr",
+		Code:    clientUser,
+	}
+	RecordQuizAnswerErr = AppError{
+		Message: "Failed to RecordQuizAnswerErr",
+		Code:    clientUser,
+	}
+	FinishQuizSessionErr = AppError{
+		Message: "Failed to FinishQuizSessionErr",
+		Code:    clientUser,
+	}
+	NextQuizPromptErr = AppError{
+		Message: "Failed to NextQuizPromptErr",
+		Code:    clientUser,
+	}
+	CheckQuizAnswerErr = AppError{
+		Message: "Failed to CheckQuizAnswerErr",
+		Code:    clientUser,
+	}
 	GetTranslationErr = AppError{
 		Message: "Failed to GetTranslationErr",
 		Code:    clientLibrary,
 	}
+	GetPhrasesErr = AppError{
+		Message: "Failed to GetPhrasesErr",
+		Code:    clientLibrary,
+	}
+	CheckPhraseErr = AppError{
+		Message: "Failed to CheckPhraseErr",
+		Code:    clientLibrary,
+	}
 	StartCompetitionErr = AppError{
 		Message: "Failed to StartCompetitionErr",
 		Code:    competition,
 	}
+	RefreshSessionErr = AppError{
+		Message: "Failed to RefreshSessionErr",
+		Code:    serviceUser,
+	}
 	LearnWordsErr = AppError{
 		Message: "Failed to LearnWordsErr",
 		Code:    serviceUser,
@@ -113,28 +223,98 @@ var (
 		Message: "Failed to TestWordsErr",
 		Code:    serviceUser,
 	}
+	SyncOutboxErr = AppError{
+		Message: "Failed to SyncOutboxErr",
+		Code:    serviceUser,
+	}
+	RefreshCacheErr = AppError{
+		Message: "Failed to RefreshCacheErr",
+		Code:    serviceUser,
+	}
 	TranslateErr = AppError{
 		Message: "Failed to TranslateErr",
 		Code:    serviceLibrary,
 	}
+	TypePhrasesErr = AppError{
+		Message: "Failed to TypePhrasesErr",
+		Code:    serviceLibrary,
+	}
 )
 
 func (appError *AppError) Error() string {
 	return appError.Code + ": " + appError.Message
 }
 
+func (appError *AppError) Unwrap() error {
+	return appError.Err
+}
+
+// Is matches every error appended to the same package error.
+func (appError *AppError) Is(target error) bool {
+	targetErr, ok := target.(*AppError)
+	if !ok {
+		return false
+	}
+
+	return appError.root() == targetErr.root()
+}
+
+func (appError *AppError) root() *AppError {
+	if appError.origin != nil {
+		return appError.origin
+	}
+
+	return appError
+}
+
+// AppendMessage keeps the code and the status, the first error of anyErrs becomes the cause.
 func (appError *AppError) AppendMessage(anyErrs ...interface{}) *AppError {
+	cause := appError.Err
+	for _, anyErr := range anyErrs {
+		if err, ok := anyErr.(error); ok {
+			cause = err
+			break
+		}
+	}
+
 	return &AppError{
-		Message: fmt.Sprintf("%v : %v", appError.Message, anyErrs),
-		Code:    appError.Code,
+		Message:  fmt.Sprintf("%v : %v", appError.Message, anyErrs),
+		Code:     appError.Code,
+		HTTPCode: appError.HTTPCode,
+		Err:      cause,
+		origin:   appError.root(),
 	}
 }
 
 func IsAppError(err1 error, err2 *AppError) bool {
-	err, ok := err1.(*AppError)
-	if !ok {
-		return false
+	return errors.Is(err1, err2)
+}
+
+// ProblemErr turns the problem document of a failed response into BadRequestErr, UnauthorizedErr, NotFoundErr,
+// ConflictErr, InProgressErr, TooManyRequestsErr or ResponseErr, code is the stable code the server sent.
+func ProblemErr(status int, code string, detail string) *AppError {
+	base := &ResponseErr
+	switch status {
+	case http.StatusBadRequest:
+		base = &BadRequestErr
+	case http.StatusUnauthorized:
+		base = &UnauthorizedErr
+	case http.StatusNotFound:
+		base = &NotFoundErr
+	case http.StatusConflict:
+		base = &ConflictErr
+		if code == idempotencyKeyInProgress {
+			base = &InProgressErr
+		}
+	case http.StatusTooManyRequests:
+		base = &TooManyRequestsErr
+	}
+
+	appErr := base.AppendMessage(status, detail)
+	appErr.HTTPCode = status
+	if code != "" {
+		appErr.Code = code
 	}
 
-	return err.Code == err2.Code
+	return appErr
 }
@@ -15,4 +15,13 @@ const (
 	competition     = "COMPETITION_ERR"
 	serviceLibrary  = "SERVICE_LIBRARY_ERR"
 	serviceUser     = "SERVICE_USER_ERR"
+	response        = "RESPONSE_ERR"
+	badRequest      = "BAD_REQUEST"
+	unauthorized    = "UNAUTHORIZED"
+	notFound        = "NOT_FOUND"
+	conflict        = "CONFLICT"
+	unreachable     = "UNREACHABLE"
+	tooManyRequests = "TOO_MANY_REQUESTS"
+
+	idempotencyKeyInProgress = "IDEMPOTENCY_KEY_IN_PROGRESS"
 )
@@ -0,0 +1,34 @@
+package clients
+
+import (
+	"client/internal/apperrors"
+	"client/internal/domain/responses"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// problemErr reads the problem document of a failed response, a body which isn't one becomes the detail.
+func problemErr(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return apperrors.ProblemErr(resp.StatusCode, "", err.Error())
+	}
+
+	problem := &responses.ProblemResponse{}
+	if err := json.Unmarshal(body, problem); err != nil || problem.Status == 0 {
+		return apperrors.ProblemErr(resp.StatusCode, "", string(body))
+	}
+
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		problem.Detail = fmt.Sprintf("%v, retry after %vs", problem.Detail, retryAfter)
+	}
+
+	return apperrors.ProblemErr(resp.StatusCode, problem.Code, problem.Detail)
+}
+
+// unreachable marks a request the server never answered, the services go on offline on it.
+func unreachable(err error) error {
+	return apperrors.UnreachableErr.AppendMessage(err)
+}
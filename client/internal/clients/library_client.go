@@ -10,8 +10,9 @@ import (
 	"client/internal/models"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/sirupsen/logrus"
 )
@@ -19,10 +20,14 @@ import (
 const (
 	pathLibrary = "/library"
 	translate   = "/translate"
+	phrases     = "/phrases"
+	checkPhrase = "/check"
 )
 
 type LibraryClient interface {
 	GetTranslation(word *requests.GetTranslationReq) ([]*models.Library, error)
+	GetPhrases(limit int) ([]*responses.PhrasePromptResp, error)
+	CheckPhrase(checkReq *requests.CheckPhraseRequest) (*responses.PhraseVerdictResp, error)
 }
 type libraryClient struct {
 	config *config.Config
@@ -41,41 +46,26 @@ func NewLibraryClient(config *config.Config, client *http.Client, log *logrus.Lo
 }
 
 func (lc libraryClient) GetTranslation(word *requests.GetTranslationReq) ([]*models.Library, error) {
-	requestBody, err := json.Marshal(word)
-	if err != nil {
-		appErr := apperrors.GetTranslationErr.AppendMessage(err)
-		lc.log.Error(appErr)
-		return nil, appErr
-	}
-
-	path := fmt.Sprintf("%v%v%v%v", lc.config.Host, lc.config.AppPort, lc.path, translate)
+	query := url.Values{"q": {word.Word}}
+	path := fmt.Sprintf("%v%v%v%v?%v", lc.config.Host, lc.config.AppPort, lc.path, translate, query.Encode())
 
-	req, err := http.NewRequest("GET", path, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequest(http.MethodGet, path, nil)
 	if err != nil {
 		appErr := apperrors.GetTranslationErr.AppendMessage(err)
 		lc.log.Error(appErr)
 		return nil, appErr
 	}
 
-	req.Header.Set("Content-Type", "application/json")
 	resp, err := lc.client.Do(req)
 	if err != nil {
-		appErr := apperrors.GetTranslationErr.AppendMessage(err)
+		appErr := apperrors.GetTranslationErr.AppendMessage(unreachable(err))
 		lc.log.Error(appErr)
 		return nil, appErr
 	}
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			appErr := apperrors.GetTranslationErr.AppendMessage(err)
-			lc.log.Error(appErr)
-			return nil, appErr
-		}
-
-		err = fmt.Errorf("GetTranslation client err [%v] is [%v]", err, string(body))
-		appErr := apperrors.GetTranslationErr.AppendMessage(err)
+		appErr := apperrors.GetTranslationErr.AppendMessage(problemErr(resp))
 		lc.log.Error(appErr)
 		return nil, appErr
 	}
@@ -91,3 +81,67 @@ func (lc libraryClient) GetTranslation(word *requests.GetTranslationReq) ([]*mod
 	words := mappers.MapGetTranslReqToGetWord(wordsResp)
 	return words, nil
 }
+
+func (lc libraryClient) GetPhrases(limit int) ([]*responses.PhrasePromptResp, error) {
+	query := url.Values{"limit": {strconv.Itoa(limit)}}
+	path := fmt.Sprintf("%v%v%v%v?%v", lc.config.Host, lc.config.AppPort, lc.path, phrases, query.Encode())
+
+	resp, err := lc.client.Get(path)
+	if err != nil {
+		appErr := apperrors.GetPhrasesErr.AppendMessage(unreachable(err))
+		lc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		appErr := apperrors.GetPhrasesErr.AppendMessage(problemErr(resp))
+		lc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	phrasesResp := []*responses.PhrasePromptResp{}
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(&phrasesResp); err != nil {
+		appErr := apperrors.GetPhrasesErr.AppendMessage(err)
+		lc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return phrasesResp, nil
+}
+
+func (lc libraryClient) CheckPhrase(checkReq *requests.CheckPhraseRequest) (*responses.PhraseVerdictResp, error) {
+	requestBody, err := json.Marshal(checkReq)
+	if err != nil {
+		appErr := apperrors.CheckPhraseErr.AppendMessage(err)
+		lc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	path := fmt.Sprintf("%v%v%v%v/%v%v", lc.config.Host, lc.config.AppPort, lc.path, phrases, checkReq.PhraseID, checkPhrase)
+
+	resp, err := lc.client.Post(path, "application/json", bytes.NewBuffer(requestBody))
+	if err != nil {
+		appErr := apperrors.CheckPhraseErr.AppendMessage(unreachable(err))
+		lc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		appErr := apperrors.CheckPhraseErr.AppendMessage(problemErr(resp))
+		lc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	verdictResp := &responses.PhraseVerdictResp{}
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(verdictResp); err != nil {
+		appErr := apperrors.CheckPhraseErr.AppendMessage(err)
+		lc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return verdictResp, nil
+}
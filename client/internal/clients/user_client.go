@@ -8,8 +8,9 @@ import (
 	"client/internal/domain/responses"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 )
@@ -20,25 +21,48 @@ const (
 	user           = "/user"
 	words          = "/words"
 	moveToLearned  = "/move-word-to-learned"
+	batch          = "/batch"
 	learn          = "/learn"
+	learned        = "/learned"
 	addWordToLearn = "/add-word-to-learn"
+	tokenRefresh   = "/token/refresh"
+	quizSessions   = "/quiz-sessions"
+	answers        = "/answers"
+	finish         = "/finish"
+	next           = "/next"
+	check          = "/check"
 )
 
 type UserClient interface {
 	CreateUser(createUsReq *requests.CreateUserRequest) (*responses.CreateUserResponse, error)
 	Login(loginReq *requests.LoginRequest) (*responses.LoginResponse, error)
-	GetUserWithWordsByIDLimit(getWordsReq *requests.GetWordsByUsIdAndLimitRequest, token string) ([]*responses.WordResp, error)
-	MoveWordToLearned(getWordsReq *requests.MoveWordToLearnedRequest, token string) error
-	AddWordToLearn(getWordsReq *requests.MoveWordToLearnedRequest, token string) error
-	GetUserWithLearnByIDLimit(getWordsReq *requests.GetWordsByUsIdAndLimitRequest, token string) ([]*responses.WordResp, error)
-	DeleteLearnWordFromUserByWord(deleteWordFromLearn *requests.DeleteLearnFromUserByIDRequest, token string) error
+	GetUserWithWordsByIDLimit(getWordsReq *requests.GetWordsByUsIdAndLimitRequest) ([]*responses.WordResp, error)
+	MoveWordToLearned(getWordsReq *requests.MoveWordToLearnedRequest) error
+	ApplyWordOperations(batchReq *requests.WordsBatchRequest) (*responses.WordsBatchResp, error)
+	AddWordToLearn(getWordsReq *requests.MoveWordToLearnedRequest) error
+	GetUserWithLearnByIDLimit(getWordsReq *requests.GetWordsByUsIdAndLimitRequest) ([]*responses.WordResp, error)
+	GetUserWithLearnedByIDLimit(getWordsReq *requests.GetWordsByUsIdAndLimitRequest) ([]*responses.WordResp, error)
+	DeleteLearnWordFromUserByWord(deleteWordFromLearn *requests.DeleteLearnFromUserByIDRequest) error
+	RefreshToken(refreshReq *requests.RefreshTokenRequest) (*responses.LoginResponse, error)
+	StartQuizSession(startReq *requests.StartQuizSessionRequest) (*responses.QuizSessionResp, error)
+	RecordQuizAnswer(answerReq *requests.QuizAnswerRequest) error
+	NextQuizPrompt(sessionID string) (*responses.QuizPromptResp, error)
+	CheckQuizAnswer(checkReq *requests.CheckQuizAnswerRequest) (*responses.QuizVerdictResp, error)
+	FinishQuizSession(finishReq *requests.FinishQuizSessionRequest) (*responses.QuizSummaryResp, error)
+	SetTokens(token string, refreshToken string)
+	OnTokensRefreshed(hook func(loginResp *responses.LoginResponse))
 }
 
+// userClient keeps the session tokens and refreshes the access token once when the server answers 401.
 type userClient struct {
-	config   *config.Config
-	client   *http.Client
-	log      *logrus.Logger
-	pathHttp string
+	config       *config.Config
+	client       *http.Client
+	log          *logrus.Logger
+	pathHttp     string
+	mu           sync.Mutex
+	token        string
+	refreshToken string
+	onRefreshed  func(loginResp *responses.LoginResponse)
 }
 
 func NewUserClient(config *config.Config, client *http.Client, log *logrus.Logger) UserClient {
@@ -71,22 +95,14 @@ func (uc *userClient) CreateUser(createUsReq *requests.CreateUserRequest) (*resp
 
 	resp, err := uc.client.Do(req)
 	if err != nil {
-		appErr := apperrors.CreateUserErr.AppendMessage(err)
+		appErr := apperrors.CreateUserErr.AppendMessage(unreachable(err))
 		uc.log.Error(appErr)
 		return nil, appErr
 	}
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusCreated {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			appErr := apperrors.CreateUserErr.AppendMessage(err)
-			uc.log.Error(appErr)
-			return nil, appErr
-		}
-
-		msg := fmt.Sprintf("error [%v] is [%v]", err, string(body))
-		appErr := apperrors.CreateUserErr.AppendMessage(msg)
+		appErr := apperrors.CreateUserErr.AppendMessage(problemErr(resp))
 		uc.log.Error(appErr)
 		return nil, appErr
 	}
@@ -123,22 +139,14 @@ func (uc *userClient) Login(loginReq *requests.LoginRequest) (*responses.LoginRe
 
 	resp, err := uc.client.Do(req)
 	if err != nil {
-		appErr := apperrors.LoginErr.AppendMessage(err)
+		appErr := apperrors.LoginErr.AppendMessage(unreachable(err))
 		uc.log.Error(appErr)
 		return nil, appErr
 	}
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			appErr := apperrors.LoginErr.AppendMessage(err)
-			uc.log.Error(appErr)
-			return nil, appErr
-		}
-
-		msg := fmt.Sprintf("err [%v] is [%v]", err, string(body))
-		appErr := apperrors.LoginErr.AppendMessage(msg)
+		appErr := apperrors.LoginErr.AppendMessage(problemErr(resp))
 		uc.log.Error(appErr)
 		return nil, appErr
 	}
@@ -154,27 +162,11 @@ func (uc *userClient) Login(loginReq *requests.LoginRequest) (*responses.LoginRe
 	return userResp, nil
 }
 
-func (uc *userClient) GetUserWithWordsByIDLimit(getWordsReq *requests.GetWordsByUsIdAndLimitRequest, token string) ([]*responses.WordResp, error) {
-	requestBody, err := json.Marshal(getWordsReq)
-	if err != nil {
-		appErr := apperrors.GetUserWithWordsByIDLimitErr.AppendMessage(err)
-		uc.log.Error(appErr)
-		return nil, appErr
-	}
-
-	path := fmt.Sprintf("%v%v%v%v", uc.config.Host, uc.config.AppPort, user, words)
-
-	req, err := http.NewRequest("GET", path, bytes.NewBuffer(requestBody))
-	if err != nil {
-		appErr := apperrors.GetUserWithWordsByIDLimitErr.AppendMessage(err)
-		uc.log.Error(appErr)
-		return nil, appErr
-	}
-
-	req.Header.Set("Authorization", token)
-	req.Header.Set("Content-Type", "application/json")
+func (uc *userClient) GetUserWithWordsByIDLimit(getWordsReq *requests.GetWordsByUsIdAndLimitRequest) ([]*responses.WordResp, error) {
+	query := url.Values{"limit": {getWordsReq.Limit}}
+	path := fmt.Sprintf("%v%v%v/%v%v?%v", uc.config.Host, uc.config.AppPort, users, url.PathEscape(getWordsReq.ID), words, query.Encode())
 
-	resp, err := uc.client.Do(req)
+	resp, err := uc.doAuthorized(http.MethodGet, path, nil, "")
 	if err != nil {
 		appErr := apperrors.GetUserWithWordsByIDLimitErr.AppendMessage(err)
 		uc.log.Error(appErr)
@@ -183,31 +175,23 @@ func (uc *userClient) GetUserWithWordsByIDLimit(getWordsReq *requests.GetWordsBy
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			appErr := apperrors.GetUserWithWordsByIDLimitErr.AppendMessage(err)
-			uc.log.Error(appErr)
-			return nil, appErr
-		}
-
-		msg := fmt.Sprintf("err [%v] is [%v]", err, string(body))
-		appErr := apperrors.GetUserWithWordsByIDLimitErr.AppendMessage(msg)
+		appErr := apperrors.GetUserWithWordsByIDLimitErr.AppendMessage(problemErr(resp))
 		uc.log.Error(appErr)
 		return nil, appErr
 	}
 
-	wordsResp := []*responses.WordResp{}
+	wordsPage := &responses.WordsPageResp{}
 	decoder := json.NewDecoder(resp.Body)
-	if err := decoder.Decode(&wordsResp); err != nil {
+	if err := decoder.Decode(wordsPage); err != nil {
 		appErr := apperrors.GetUserWithWordsByIDLimitErr.AppendMessage(err)
 		uc.log.Error(appErr)
 		return nil, appErr
 	}
 
-	return wordsResp, nil
+	return wordsPage.Words, nil
 }
 
-func (uc *userClient) MoveWordToLearned(getWordsReq *requests.MoveWordToLearnedRequest, token string) error {
+func (uc *userClient) MoveWordToLearned(getWordsReq *requests.MoveWordToLearnedRequest) error {
 	requestBody, err := json.Marshal(getWordsReq)
 	if err != nil {
 		appErr := apperrors.MoveWordToLearnedErr.AppendMessage(err)
@@ -217,17 +201,7 @@ func (uc *userClient) MoveWordToLearned(getWordsReq *requests.MoveWordToLearnedR
 
 	path := fmt.Sprintf("%v%v%v%v", uc.config.Host, uc.config.AppPort, user, moveToLearned)
 
-	req, err := http.NewRequest("PUT", path, bytes.NewBuffer(requestBody))
-	if err != nil {
-		appErr := apperrors.MoveWordToLearnedErr.AppendMessage(err)
-		uc.log.Error(appErr)
-		return appErr
-	}
-
-	req.Header.Set("Authorization", token)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := uc.client.Do(req)
+	resp, err := uc.doAuthorized(http.MethodPut, path, requestBody, getWordsReq.IdempotencyKey)
 	if err != nil {
 		appErr := apperrors.MoveWordToLearnedErr.AppendMessage(err)
 		uc.log.Error(appErr)
@@ -236,15 +210,7 @@ func (uc *userClient) MoveWordToLearned(getWordsReq *requests.MoveWordToLearnedR
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			appErr := apperrors.MoveWordToLearnedErr.AppendMessage(err)
-			uc.log.Error(appErr)
-			return appErr
-		}
-
-		msg := fmt.Errorf("err [%v] is [%v]", err, string(body))
-		appErr := apperrors.MoveWordToLearnedErr.AppendMessage(msg)
+		appErr := apperrors.MoveWordToLearnedErr.AppendMessage(problemErr(resp))
 		uc.log.Error(appErr)
 		return appErr
 	}
@@ -262,27 +228,53 @@ func (uc *userClient) MoveWordToLearned(getWordsReq *requests.MoveWordToLearnedR
 	return nil
 }
 
-func (uc *userClient) AddWordToLearn(getWordsReq *requests.MoveWordToLearnedRequest, token string) error {
-	requestBody, err := json.Marshal(getWordsReq)
+// ApplyWordOperations sends the word list changes of a session in one call, the server applies them in one transaction.
+func (uc *userClient) ApplyWordOperations(batchReq *requests.WordsBatchRequest) (*responses.WordsBatchResp, error) {
+	requestBody, err := json.Marshal(batchReq)
 	if err != nil {
-		appErr := apperrors.AddWordToLearnErr.AppendMessage(err)
+		appErr := apperrors.ApplyWordOperationsErr.AppendMessage(err)
 		uc.log.Error(appErr)
-		return appErr
+		return nil, appErr
 	}
 
-	path := fmt.Sprintf("%v%v%v%v", uc.config.Host, uc.config.AppPort, user, addWordToLearn)
+	path := fmt.Sprintf("%v%v%v%v%v", uc.config.Host, uc.config.AppPort, user, words, batch)
 
-	req, err := http.NewRequest("POST", path, bytes.NewBuffer(requestBody))
+	resp, err := uc.doAuthorized(http.MethodPost, path, requestBody, batchReq.IdempotencyKey)
+	if err != nil {
+		appErr := apperrors.ApplyWordOperationsErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		appErr := apperrors.ApplyWordOperationsErr.AppendMessage(problemErr(resp))
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	batchResp := &responses.WordsBatchResp{}
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(batchResp); err != nil {
+		appErr := apperrors.ApplyWordOperationsErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return batchResp, nil
+}
+
+func (uc *userClient) AddWordToLearn(getWordsReq *requests.MoveWordToLearnedRequest) error {
+	requestBody, err := json.Marshal(getWordsReq)
 	if err != nil {
 		appErr := apperrors.AddWordToLearnErr.AppendMessage(err)
 		uc.log.Error(appErr)
 		return appErr
 	}
 
-	req.Header.Set("Authorization", token)
-	req.Header.Set("Content-Type", "application/json")
+	path := fmt.Sprintf("%v%v%v%v", uc.config.Host, uc.config.AppPort, user, addWordToLearn)
 
-	resp, err := uc.client.Do(req)
+	resp, err := uc.doAuthorized(http.MethodPost, path, requestBody, getWordsReq.IdempotencyKey)
 	if err != nil {
 		appErr := apperrors.AddWordToLearnErr.AppendMessage(err)
 		uc.log.Error(appErr)
@@ -291,15 +283,7 @@ func (uc *userClient) AddWordToLearn(getWordsReq *requests.MoveWordToLearnedRequ
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			appErr := apperrors.AddWordToLearnErr.AppendMessage(err)
-			uc.log.Error(appErr)
-			return appErr
-		}
-
-		msg := fmt.Errorf("err [%v] is [%v]", err, string(body))
-		appErr := apperrors.AddWordToLearnErr.AppendMessage(msg)
+		appErr := apperrors.AddWordToLearnErr.AppendMessage(problemErr(resp))
 		uc.log.Error(appErr)
 		return appErr
 	}
@@ -317,60 +301,65 @@ func (uc *userClient) AddWordToLearn(getWordsReq *requests.MoveWordToLearnedRequ
 	return nil
 }
 
-func (uc *userClient) GetUserWithLearnByIDLimit(getWordsReq *requests.GetWordsByUsIdAndLimitRequest, token string) ([]*responses.WordResp, error) {
-	requestBody, err := json.Marshal(getWordsReq)
+func (uc *userClient) GetUserWithLearnByIDLimit(getWordsReq *requests.GetWordsByUsIdAndLimitRequest) ([]*responses.WordResp, error) {
+	query := url.Values{"limit": {getWordsReq.Limit}}
+	path := fmt.Sprintf("%v%v%v/%v%v?%v", uc.config.Host, uc.config.AppPort, users, url.PathEscape(getWordsReq.ID), learn, query.Encode())
+
+	resp, err := uc.doAuthorized(http.MethodGet, path, nil, "")
 	if err != nil {
 		appErr := apperrors.GetUserWithLearnByIDLimitErr.AppendMessage(err)
 		uc.log.Error(appErr)
 		return nil, appErr
 	}
 
-	path := fmt.Sprintf("%v%v%v%v", uc.config.Host, uc.config.AppPort, user, learn)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		appErr := apperrors.GetUserWithLearnByIDLimitErr.AppendMessage(problemErr(resp))
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
 
-	req, err := http.NewRequest("GET", path, bytes.NewBuffer(requestBody))
-	if err != nil {
+	wordsPage := &responses.WordsPageResp{}
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(wordsPage); err != nil {
 		appErr := apperrors.GetUserWithLearnByIDLimitErr.AppendMessage(err)
 		uc.log.Error(appErr)
 		return nil, appErr
 	}
 
-	req.Header.Set("Authorization", token)
-	req.Header.Set("Content-Type", "application/json")
+	return wordsPage.Words, nil
+}
 
-	resp, err := uc.client.Do(req)
+func (uc *userClient) GetUserWithLearnedByIDLimit(getWordsReq *requests.GetWordsByUsIdAndLimitRequest) ([]*responses.WordResp, error) {
+	query := url.Values{"limit": {getWordsReq.Limit}}
+	path := fmt.Sprintf("%v%v%v/%v%v?%v", uc.config.Host, uc.config.AppPort, users, url.PathEscape(getWordsReq.ID), learned, query.Encode())
+
+	resp, err := uc.doAuthorized(http.MethodGet, path, nil, "")
 	if err != nil {
-		appErr := apperrors.GetUserWithLearnByIDLimitErr.AppendMessage(err)
+		appErr := apperrors.GetUserWithLearnedByIDLimitErr.AppendMessage(err)
 		uc.log.Error(appErr)
 		return nil, appErr
 	}
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			appErr := apperrors.GetUserWithLearnByIDLimitErr.AppendMessage(err)
-			uc.log.Error(appErr)
-			return nil, appErr
-		}
-
-		msg := fmt.Sprintf("err [%v] is [%v]", err, string(body))
-		appErr := apperrors.GetUserWithLearnByIDLimitErr.AppendMessage(msg)
+		appErr := apperrors.GetUserWithLearnedByIDLimitErr.AppendMessage(problemErr(resp))
 		uc.log.Error(appErr)
 		return nil, appErr
 	}
 
-	wordsResp := []*responses.WordResp{}
+	wordsPage := &responses.WordsPageResp{}
 	decoder := json.NewDecoder(resp.Body)
-	if err := decoder.Decode(&wordsResp); err != nil {
-		appErr := apperrors.GetUserWithLearnByIDLimitErr.AppendMessage(err)
+	if err := decoder.Decode(wordsPage); err != nil {
+		appErr := apperrors.GetUserWithLearnedByIDLimitErr.AppendMessage(err)
 		uc.log.Error(appErr)
 		return nil, appErr
 	}
 
-	return wordsResp, nil
+	return wordsPage.Words, nil
 }
 
-func (uc *userClient) DeleteLearnWordFromUserByWord(deleteWordFromLearn *requests.DeleteLearnFromUserByIDRequest, token string) error {
+func (uc *userClient) DeleteLearnWordFromUserByWord(deleteWordFromLearn *requests.DeleteLearnFromUserByIDRequest) error {
 	requestBody, err := json.Marshal(deleteWordFromLearn)
 	if err != nil {
 		appErr := apperrors.DeleteLearnWordFromUserByWordErr.AppendMessage(err)
@@ -380,45 +369,318 @@ func (uc *userClient) DeleteLearnWordFromUserByWord(deleteWordFromLearn *request
 
 	path := fmt.Sprintf("%v%v%v%v", uc.config.Host, uc.config.AppPort, user, learn)
 
-	req, err := http.NewRequest("DELETE", path, bytes.NewBuffer(requestBody))
+	resp, err := uc.doAuthorized(http.MethodDelete, path, requestBody, deleteWordFromLearn.IdempotencyKey)
 	if err != nil {
 		appErr := apperrors.DeleteLearnWordFromUserByWordErr.AppendMessage(err)
 		uc.log.Error(appErr)
 		return appErr
 	}
 
-	req.Header.Set("Authorization", token)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		appErr := apperrors.DeleteLearnWordFromUserByWordErr.AppendMessage(problemErr(resp))
+		uc.log.Error(appErr)
+		return appErr
+	}
+
+	wordsResp := &responses.Result{}
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(&wordsResp); err != nil {
+		appErr := apperrors.DeleteLearnWordFromUserByWordErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+func (uc *userClient) RefreshToken(refreshReq *requests.RefreshTokenRequest) (*responses.LoginResponse, error) {
+	requestBody, err := json.Marshal(refreshReq)
+	if err != nil {
+		appErr := apperrors.RefreshTokenErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	path := fmt.Sprintf("%v%v%v%v", uc.config.Host, uc.config.AppPort, uc.pathHttp, tokenRefresh)
+
+	req, err := http.NewRequest("POST", path, bytes.NewBuffer(requestBody))
+	if err != nil {
+		appErr := apperrors.RefreshTokenErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := uc.client.Do(req)
 	if err != nil {
-		appErr := apperrors.DeleteLearnWordFromUserByWordErr.AppendMessage(err)
+		appErr := apperrors.RefreshTokenErr.AppendMessage(unreachable(err))
 		uc.log.Error(appErr)
-		return appErr
+		return nil, appErr
 	}
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			appErr := apperrors.DeleteLearnWordFromUserByWordErr.AppendMessage(err)
-			uc.log.Error(appErr)
-			return appErr
-		}
+		appErr := apperrors.RefreshTokenErr.AppendMessage(problemErr(resp))
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	loginResp := &responses.LoginResponse{}
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(loginResp); err != nil {
+		appErr := apperrors.RefreshTokenErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return loginResp, nil
+}
+
+func (uc *userClient) StartQuizSession(startReq *requests.StartQuizSessionRequest) (*responses.QuizSessionResp, error) {
+	requestBody, err := json.Marshal(startReq)
+	if err != nil {
+		appErr := apperrors.StartQuizSessionErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	path := fmt.Sprintf("%v%v%v%v", uc.config.Host, uc.config.AppPort, user, quizSessions)
+
+	resp, err := uc.doAuthorized(http.MethodPost, path, requestBody, startReq.IdempotencyKey)
+	if err != nil {
+		appErr := apperrors.StartQuizSessionErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
 
-		msg := fmt.Sprintf("err [%v] is [%v]", err, string(body))
-		appErr := apperrors.DeleteLearnWordFromUserByWordErr.AppendMessage(msg)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		appErr := apperrors.StartQuizSessionErr.AppendMessage(problemErr(resp))
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	sessionResp := &responses.QuizSessionResp{}
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(sessionResp); err != nil {
+		appErr := apperrors.StartQuizSessionErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return sessionResp, nil
+}
+
+func (uc *userClient) RecordQuizAnswer(answerReq *requests.QuizAnswerRequest) error {
+	requestBody, err := json.Marshal(answerReq)
+	if err != nil {
+		appErr := apperrors.RecordQuizAnswerErr.AppendMessage(err)
 		uc.log.Error(appErr)
 		return appErr
 	}
 
-	wordsResp := &responses.Result{}
+	path := fmt.Sprintf("%v%v%v%v/%v%v", uc.config.Host, uc.config.AppPort, user, quizSessions, url.PathEscape(answerReq.SessionID), answers)
+
+	resp, err := uc.doAuthorized(http.MethodPost, path, requestBody, answerReq.IdempotencyKey)
+	if err != nil {
+		appErr := apperrors.RecordQuizAnswerErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return appErr
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		appErr := apperrors.RecordQuizAnswerErr.AppendMessage(problemErr(resp))
+		uc.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+func (uc *userClient) NextQuizPrompt(sessionID string) (*responses.QuizPromptResp, error) {
+	path := fmt.Sprintf("%v%v%v%v/%v%v", uc.config.Host, uc.config.AppPort, user, quizSessions, url.PathEscape(sessionID), next)
+
+	resp, err := uc.doAuthorized(http.MethodGet, path, nil, "")
+	if err != nil {
+		appErr := apperrors.NextQuizPromptErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		appErr := apperrors.NextQuizPromptErr.AppendMessage(problemErr(resp))
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	promptResp := &responses.QuizPromptResp{}
 	decoder := json.NewDecoder(resp.Body)
-	if err := decoder.Decode(&wordsResp); err != nil {
-		appErr := apperrors.DeleteLearnWordFromUserByWordErr.AppendMessage(err)
+	if err := decoder.Decode(promptResp); err != nil {
+		appErr := apperrors.NextQuizPromptErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return promptResp, nil
+}
+
+func (uc *userClient) CheckQuizAnswer(checkReq *requests.CheckQuizAnswerRequest) (*responses.QuizVerdictResp, error) {
+	requestBody, err := json.Marshal(checkReq)
+	if err != nil {
+		appErr := apperrors.CheckQuizAnswerErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	path := fmt.Sprintf("%v%v%v%v/%v%v", uc.config.Host, uc.config.AppPort, user, quizSessions, url.PathEscape(checkReq.SessionID), check)
+
+	resp, err := uc.doAuthorized(http.MethodPost, path, requestBody, "")
+	if err != nil {
+		appErr := apperrors.CheckQuizAnswerErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		appErr := apperrors.CheckQuizAnswerErr.AppendMessage(problemErr(resp))
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	verdictResp := &responses.QuizVerdictResp{}
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(verdictResp); err != nil {
+		appErr := apperrors.CheckQuizAnswerErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return verdictResp, nil
+}
+
+func (uc *userClient) FinishQuizSession(finishReq *requests.FinishQuizSessionRequest) (*responses.QuizSummaryResp, error) {
+	requestBody, err := json.Marshal(finishReq)
+	if err != nil {
+		appErr := apperrors.FinishQuizSessionErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	path := fmt.Sprintf("%v%v%v%v/%v%v", uc.config.Host, uc.config.AppPort, user, quizSessions, url.PathEscape(finishReq.SessionID), finish)
+
+	resp, err := uc.doAuthorized(http.MethodPost, path, requestBody, "")
+	if err != nil {
+		appErr := apperrors.FinishQuizSessionErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		appErr := apperrors.FinishQuizSessionErr.AppendMessage(problemErr(resp))
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	summaryResp := &responses.QuizSummaryResp{}
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(summaryResp); err != nil {
+		appErr := apperrors.FinishQuizSessionErr.AppendMessage(err)
+		uc.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return summaryResp, nil
+}
+
+func (uc *userClient) SetTokens(token string, refreshToken string) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.token = token
+	uc.refreshToken = refreshToken
+}
+
+func (uc *userClient) OnTokensRefreshed(hook func(loginResp *responses.LoginResponse)) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.onRefreshed = hook
+}
+
+// doAuthorized sends the request with the access token, a non empty idempotencyKey goes in the Idempotency-Key header
+// so the server runs a retried mutation only once.
+func (uc *userClient) doAuthorized(method string, path string, requestBody []byte, idempotencyKey string) (*http.Response, error) {
+	resp, err := uc.sendAuthorized(method, path, requestBody, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	resp.Body.Close()
+	if err := uc.refreshTokens(); err != nil {
+		return nil, err
+	}
+
+	return uc.sendAuthorized(method, path, requestBody, idempotencyKey)
+}
+
+func (uc *userClient) sendAuthorized(method string, path string, requestBody []byte, idempotencyKey string) (*http.Response, error) {
+	req, err := http.NewRequest(method, path, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, err
+	}
+
+	uc.mu.Lock()
+	token := uc.token
+	uc.mu.Unlock()
+
+	req.Header.Set("Authorization", token)
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := uc.client.Do(req)
+	if err != nil {
+		return nil, unreachable(err)
+	}
+
+	return resp, nil
+}
+
+func (uc *userClient) refreshTokens() error {
+	uc.mu.Lock()
+	refreshToken := uc.refreshToken
+	uc.mu.Unlock()
+
+	if refreshToken == "" {
+		appErr := apperrors.RefreshTokenErr.AppendMessage("there is no refresh token, login again")
 		uc.log.Error(appErr)
 		return appErr
 	}
 
+	loginResp, err := uc.RefreshToken(&requests.RefreshTokenRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return err
+	}
+
+	uc.SetTokens(loginResp.Token, loginResp.RefreshToken)
+	uc.log.Info("access token has been refreshed")
+
+	uc.mu.Lock()
+	hook := uc.onRefreshed
+	uc.mu.Unlock()
+
+	if hook != nil {
+		hook(loginResp)
+	}
+
 	return nil
 }
@@ -15,24 +15,28 @@ type Competition struct {
 	clientLibrary clients.LibraryClient
 	clientUser    clients.UserClient
 	repoBackup    repositories.BackupRepo
+	repoCache     repositories.CacheRepo
+	repoOutbox    repositories.OutboxRepo
 	log           *logrus.Logger
 }
 
-func NewCompetition(clientLibrary clients.LibraryClient, clientUser clients.UserClient,
-	repoBackup repositories.BackupRepo, log *logrus.Logger) *Competition {
+func NewCompetition(clientLibrary clients.LibraryClient, clientUser clients.UserClient, repoBackup repositories.BackupRepo,
+	repoCache repositories.CacheRepo, repoOutbox repositories.OutboxRepo, log *logrus.Logger) *Competition {
 	return &Competition{
 		clientLibrary: clientLibrary,
 		clientUser:    clientUser,
 		repoBackup:    repoBackup,
+		repoCache:     repoCache,
+		repoOutbox:    repoOutbox,
 		log:           log,
 	}
 }
 
 func (c *Competition) StartCompetition(ctx context.Context) error {
 	user, err := c.userExistOrRegistration(ctx)
-
 	if err != nil {
 		c.log.Error(err)
+		return err
 	}
 
 	for {
@@ -72,6 +76,11 @@ func (c *Competition) scanCommand(ctx context.Context, user *models.User) (bool,
 			return false, err
 		}
 
+	case phrases:
+		if err := c.phrases(ctx); err != nil {
+			return false, err
+		}
+
 	case exit:
 		fmt.Println("    Good buy, have a good day !!!")
 		return true, nil
@@ -85,6 +94,7 @@ func printInfoMenu() {
 		fmt.Sprintf("      Test knowledge:   [%v]\n", test),
 		fmt.Sprintf("      Learn words:     [%v]\n", learn),
 		fmt.Sprintf("      Translator:  [%v]\n", translate),
+		fmt.Sprintf("      Type phrases:  [%v]\n", phrases),
 		fmt.Sprintf("          Exit:        [%v]\n", exit),
 	}
 
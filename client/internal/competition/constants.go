@@ -4,7 +4,14 @@ var (
 	test                    = "test"
 	learn                   = "learn"
 	translate               = "translate"
+	phrases                 = "phrases"
 	exit                    = "exit"
+	russianEnglish          = "ru-en"
+	englishRussian          = "en-ru"
+	mix                     = "mix"
+	choice                  = "choice"
 	numberOfWordsForTheTest = "Number of words for the test"
+	numberOfPhrases         = "Number of phrases"
+	chooseQuizMode          = "Mode: Russian -> English [ru-en], English -> Russian [en-ru], random mix [mix], multiple choice [choice]"
 	enterAWorldOfAPart      = "Enter a word or part of a word"
 )
@@ -9,27 +9,56 @@ import (
 
 func (c *Competition) translator(ctx context.Context) error {
 	fmt.Println(enterAWorldOfAPart)
-	libServ := services.NewLibraryService(c.clientLibrary, c.log)
+	libServ := services.NewLibraryService(c.clientLibrary, c.repoCache, c.log)
 	return libServ.Translate(ctx)
 }
 
 func (c *Competition) userExistOrRegistration(ctx context.Context) (*models.User, error) {
-	userService := services.NewUserService(c.clientUser, c.clientLibrary, c.repoBackup, c.log)
+	userService := services.NewUserService(c.clientUser, c.clientLibrary, c.repoBackup, c.repoCache, c.repoOutbox, c.log)
 	return userService.UserExistsOrRegistration(ctx)
 }
 
 func (c *Competition) test(ctx context.Context, user *models.User) error {
+	mode := scanQuizMode()
 	var quantity int
 	fmt.Println(numberOfWordsForTheTest)
 	fmt.Scan(&quantity)
-	userService := services.NewUserService(c.clientUser, c.clientLibrary, c.repoBackup, c.log)
-	return userService.TestWords(ctx, user, quantity)
+	userService := services.NewUserService(c.clientUser, c.clientLibrary, c.repoBackup, c.repoCache, c.repoOutbox, c.log)
+	return userService.TestWords(ctx, user, quantity, mode)
 }
 
 func (c *Competition) learn(ctx context.Context, user *models.User) error {
+	mode := scanQuizMode()
 	var quantity int
 	fmt.Println(numberOfWordsForTheTest)
 	fmt.Scan(&quantity)
-	userService := services.NewUserService(c.clientUser, c.clientLibrary, c.repoBackup, c.log)
-	return userService.LearnWords(ctx, quantity, user)
+	userService := services.NewUserService(c.clientUser, c.clientLibrary, c.repoBackup, c.repoCache, c.repoOutbox, c.log)
+	return userService.LearnWords(ctx, quantity, user, mode)
+}
+
+func (c *Competition) phrases(ctx context.Context) error {
+	var quantity int
+	fmt.Println(numberOfPhrases)
+	fmt.Scan(&quantity)
+	libServ := services.NewLibraryService(c.clientLibrary, c.repoCache, c.log)
+	return libServ.TypePhrases(ctx, quantity)
+}
+
+var quizModes = map[string]services.QuizMode{
+	russianEnglish: services.QuizRussianEnglish,
+	englishRussian: services.QuizEnglishRussian,
+	mix:            services.QuizMix,
+	choice:         services.QuizMultipleChoice,
+}
+
+// scanQuizMode asks until a known mode is typed.
+func scanQuizMode() services.QuizMode {
+	for {
+		fmt.Println(chooseQuizMode)
+		var command string
+		fmt.Scan(&command)
+		if mode, ok := quizModes[command]; ok {
+			return mode
+		}
+	}
 }
@@ -1,5 +1,7 @@
 package requests
 
+import "time"
+
 type GetTranslationReq struct {
 	Word string `json:"word"`
 }
@@ -9,7 +11,6 @@ type CreateUserRequest struct {
 	Name     string `json:"name"`
 	LastName string `json:"last_name"`
 	Password string `json:"password"`
-	Role     string `json:"role"`
 }
 
 type GetWordsByUsIdAndLimitRequest struct {
@@ -22,14 +23,30 @@ type DeleteWordFromUserByIDRequest struct {
 	WordID string `json:"word_id"`
 }
 
+// DeleteLearnFromUserByIDRequest is sent with IdempotencyKey in the Idempotency-Key header, a retry with it is applied once.
 type DeleteLearnFromUserByIDRequest struct {
-	UserID string `json:"user_id"`
-	WordID string `json:"word_id"`
+	UserID         string `json:"user_id"`
+	WordID         string `json:"word_id"`
+	IdempotencyKey string `json:"-"`
 }
 
+// MoveWordToLearnedRequest is sent with IdempotencyKey in the Idempotency-Key header, a retry with it is applied once.
 type MoveWordToLearnedRequest struct {
-	UserID string `json:"user_id"`
+	UserID         string `json:"user_id"`
+	WordID         string `json:"word_id"`
+	IdempotencyKey string `json:"-"`
+}
+
+// WordsBatchRequest applies the Operations in one transaction, IdempotencyKey goes in the Idempotency-Key header.
+type WordsBatchRequest struct {
+	UserID         string                  `json:"user_id"`
+	Operations     []*WordOperationRequest `json:"operations"`
+	IdempotencyKey string                  `json:"-"`
+}
+
+type WordOperationRequest struct {
 	WordID string `json:"word_id"`
+	Action string `json:"action"`
 }
 
 type AddWordToLearnedRequest struct {
@@ -40,4 +57,46 @@ type AddWordToLearnedRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Device   string `json:"device"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// StartQuizSessionRequest starts a session, IdempotencyKey goes in the Idempotency-Key header.
+type StartQuizSessionRequest struct {
+	Mode           string    `json:"mode"`
+	Size           int       `json:"size,omitempty"`
+	Direction      string    `json:"direction,omitempty"`
+	Choices        int       `json:"choices,omitempty"`
+	StartedAt      time.Time `json:"started_at"`
+	IdempotencyKey string    `json:"-"`
+}
+
+// QuizAnswerRequest reports an answer graded by the client, IdempotencyKey goes in the Idempotency-Key header.
+type QuizAnswerRequest struct {
+	SessionID      string    `json:"session_id"`
+	WordID         string    `json:"word_id"`
+	Correct        bool      `json:"correct"`
+	Typo           bool      `json:"typo"`
+	LatencyMs      int64     `json:"latency_ms"`
+	AnsweredAt     time.Time `json:"answered_at"`
+	IdempotencyKey string    `json:"-"`
+}
+
+type CheckQuizAnswerRequest struct {
+	SessionID string `json:"session_id"`
+	Position  int    `json:"position"`
+	Answer    string `json:"answer"`
+}
+
+type CheckPhraseRequest struct {
+	PhraseID int    `json:"phrase_id"`
+	Answer   string `json:"answer"`
+}
+
+type FinishQuizSessionRequest struct {
+	SessionID  string    `json:"session_id"`
+	FinishedAt time.Time `json:"finished_at"`
 }
@@ -1,5 +1,7 @@
 package responses
 
+import "time"
+
 type GetTranslationResp struct {
 	English string `json:"english"`
 	Russian string `json:"russian"`
@@ -14,10 +16,11 @@ type Result struct {
 }
 
 type LoginResponse struct {
-	Token        string `json:"token"`
-	TokenType    string `json:"token_type"`
-	ExpiresIn    string `json:"expires_in"`
-	RefreshToken string `json:"refresh_token"`
+	Token            string `json:"token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        string `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn string `json:"refresh_expires_in"`
 }
 
 type WordResp struct {
@@ -25,3 +28,82 @@ type WordResp struct {
 	English string `json:"english"`
 	Russian string `json:"russian"`
 }
+
+type WordsBatchResp struct {
+	Applied int                  `json:"applied"`
+	Results []*WordOperationResp `json:"results"`
+}
+
+type WordOperationResp struct {
+	WordID string `json:"word_id"`
+	Action string `json:"action"`
+	Status string `json:"status"`
+}
+
+type WordsPageResp struct {
+	Words      []*WordResp `json:"words"`
+	Total      int64       `json:"total"`
+	NextCursor string      `json:"next_cursor"`
+}
+
+// ProblemResponse is the problem document the server answers an error with.
+type ProblemResponse struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+type QuizSessionResp struct {
+	ID         string     `json:"id"`
+	Mode       string     `json:"mode"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+type QuizPromptResp struct {
+	SessionID      string   `json:"session_id"`
+	Done           bool     `json:"done"`
+	Position       int      `json:"position"`
+	WordID         string   `json:"word_id"`
+	Prompt         string   `json:"prompt"`
+	Language       string   `json:"language"`
+	AnswerLanguage string   `json:"answer_language"`
+	Theme          string   `json:"theme"`
+	PartsOfSpeech  string   `json:"part_of_speech"`
+	Choices        []string `json:"choices"`
+	Remaining      int64    `json:"remaining"`
+	Total          int64    `json:"total"`
+}
+
+type PhrasePromptResp struct {
+	ID     int    `json:"id"`
+	Prompt string `json:"prompt"`
+}
+
+type PhraseVerdictResp struct {
+	PhraseID int    `json:"phrase_id"`
+	Correct  bool   `json:"correct"`
+	Typo     bool   `json:"typo"`
+	Answer   string `json:"answer"`
+	Expected string `json:"expected"`
+}
+
+type QuizVerdictResp struct {
+	Position  int    `json:"position"`
+	WordID    string `json:"word_id"`
+	Correct   bool   `json:"correct"`
+	Typo      bool   `json:"typo"`
+	Answer    string `json:"answer"`
+	Expected  string `json:"expected"`
+	Remaining int64  `json:"remaining"`
+}
+
+type QuizSummaryResp struct {
+	QuizSessionResp
+	Right           int64   `json:"right"`
+	Wrong           int64   `json:"wrong"`
+	Typos           int64   `json:"typos"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
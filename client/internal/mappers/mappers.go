@@ -26,6 +26,20 @@ func MapCreateUserReqToUser(createUsReq *requests.CreateUserRequest) *models.Use
 		Name:     createUsReq.Name,
 		LastName: createUsReq.LastName,
 		Password: createUsReq.Password,
-		Role:     createUsReq.Role,
 	}
 }
+
+func MapWordRespToWords(wordsResp []*responses.WordResp) []*models.Word {
+	words := []*models.Word{}
+	for _, wordResp := range wordsResp {
+		word := &models.Word{
+			ID:      wordResp.ID,
+			English: wordResp.English,
+			Russian: wordResp.Russian,
+		}
+
+		words = append(words, word)
+	}
+
+	return words
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Kinds of the outbox operations.
+const (
+	OpMoveToLearned = "move_to_learned"
+	OpAddToLearn    = "add_to_learn"
+	OpDeleteLearn   = "delete_learn"
+	OpQuizSession   = "quiz_session"
+)
+
+// Cache keeps the lists of the user and the translations looked up, the client reads it when the server is unreachable.
+type Cache struct {
+	UserID       string                `json:"user_id"`
+	Words        []*Word               `json:"user_words"`
+	Learn        []*Word               `json:"user_learn"`
+	Learned      []*Word               `json:"user_learned"`
+	Translations map[string][]*Library `json:"translations"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+}
+
+// Outbox holds the operations made offline, Seq keeps their order.
+type Outbox struct {
+	NextSeq int64       `json:"next_seq"`
+	Ops     []*OutboxOp `json:"ops"`
+}
+
+// OutboxOp is a call the server hasn't got yet, a word list change or a whole quiz session.
+type OutboxOp struct {
+	ID        string          `json:"id"`
+	Seq       int64           `json:"seq"`
+	Kind      string          `json:"kind"`
+	UserID    string          `json:"user_id"`
+	WordID    string          `json:"word_id,omitempty"`
+	Session   *OfflineSession `json:"session,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// OfflineSession is a quiz played offline. ServerID and Recorded keep how far its replay went.
+type OfflineSession struct {
+	Mode       string           `json:"mode"`
+	Direction  string           `json:"direction"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+	Answers    []*OfflineAnswer `json:"answers"`
+	ServerID   string           `json:"server_id,omitempty"`
+	Recorded   int              `json:"recorded"`
+}
+
+type OfflineAnswer struct {
+	WordID     string    `json:"word_id"`
+	Correct    bool      `json:"correct"`
+	Typo       bool      `json:"typo"`
+	LatencyMs  int64     `json:"latency_ms"`
+	AnsweredAt time.Time `json:"answered_at"`
+}
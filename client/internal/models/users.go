@@ -16,6 +16,7 @@ type User struct {
 	Learned      []*Word `json:"user_learned"`
 	Token        string
 	TokenExpired string
+	RefreshToken string
 }
 
 type Word struct {
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"client/internal/apperrors"
+	"client/internal/models"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+const cache = "backup/cache.json"
+
+type CacheRepo interface {
+	GetCache() (*models.Cache, error)
+	SaveCache(cache *models.Cache) error
+}
+
+type cacheRepo struct {
+	path string
+	log  *logrus.Logger
+}
+
+func NewCacheRepo(log *logrus.Logger) CacheRepo {
+	return &cacheRepo{path: cache, log: log}
+}
+
+// GetCache returns an empty cache until the first one is saved.
+func (cr *cacheRepo) GetCache() (*models.Cache, error) {
+	data, err := os.ReadFile(cr.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &models.Cache{Translations: map[string][]*models.Library{}}, nil
+	}
+
+	if err != nil {
+		appErr := apperrors.GetCacheErr.AppendMessage(err)
+		cr.log.Error(appErr)
+		return nil, appErr
+	}
+
+	cache := &models.Cache{}
+	if err := json.Unmarshal(data, cache); err != nil {
+		appErr := apperrors.GetCacheErr.AppendMessage(err)
+		cr.log.Error(appErr)
+		return nil, appErr
+	}
+
+	if cache.Translations == nil {
+		cache.Translations = map[string][]*models.Library{}
+	}
+
+	return cache, nil
+}
+
+func (cr *cacheRepo) SaveCache(cache *models.Cache) error {
+	data, err := json.MarshalIndent(cache, "", "   ")
+	if err != nil {
+		appErr := apperrors.SaveCacheErr.AppendMessage(err)
+		cr.log.Error(appErr)
+		return appErr
+	}
+
+	if err := writeFileDurable(cr.path, data); err != nil {
+		appErr := apperrors.SaveCacheErr.AppendMessage(err)
+		cr.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"client/internal/apperrors"
+	"client/internal/models"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const outbox = "backup/outbox.json"
+
+// OutboxRepo keeps the operations made offline on the disk until the server acknowledges them.
+type OutboxRepo interface {
+	Append(op *models.OutboxOp) error
+	Pending() ([]*models.OutboxOp, error)
+	Update(op *models.OutboxOp) error
+	Ack(ids ...string) error
+}
+
+type outboxRepo struct {
+	path string
+	mu   sync.Mutex
+	log  *logrus.Logger
+}
+
+func NewOutboxRepo(log *logrus.Logger) OutboxRepo {
+	return &outboxRepo{path: outbox, log: log}
+}
+
+// Append gives the operation its ID and the next Seq, it is on the disk when Append returns.
+func (obr *outboxRepo) Append(op *models.OutboxOp) error {
+	obr.mu.Lock()
+	defer obr.mu.Unlock()
+
+	box, err := obr.read()
+	if err != nil {
+		return err
+	}
+
+	id, err := newOpID()
+	if err != nil {
+		appErr := apperrors.SaveOutboxErr.AppendMessage(err)
+		obr.log.Error(appErr)
+		return appErr
+	}
+
+	box.NextSeq++
+	op.ID = id
+	op.Seq = box.NextSeq
+	op.CreatedAt = time.Now()
+	box.Ops = append(box.Ops, op)
+	return obr.write(box)
+}
+
+// Pending returns the operations in the order they were made.
+func (obr *outboxRepo) Pending() ([]*models.OutboxOp, error) {
+	obr.mu.Lock()
+	defer obr.mu.Unlock()
+
+	box, err := obr.read()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(box.Ops, func(i, j int) bool { return box.Ops[i].Seq < box.Ops[j].Seq })
+	return box.Ops, nil
+}
+
+// Update saves how far the replay of an operation went.
+func (obr *outboxRepo) Update(op *models.OutboxOp) error {
+	obr.mu.Lock()
+	defer obr.mu.Unlock()
+
+	box, err := obr.read()
+	if err != nil {
+		return err
+	}
+
+	for i, stored := range box.Ops {
+		if stored.ID == op.ID {
+			box.Ops[i] = op
+			return obr.write(box)
+		}
+	}
+
+	return nil
+}
+
+// Ack removes the operations the server has taken, an unknown ID is already removed.
+func (obr *outboxRepo) Ack(ids ...string) error {
+	obr.mu.Lock()
+	defer obr.mu.Unlock()
+
+	box, err := obr.read()
+	if err != nil {
+		return err
+	}
+
+	acked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		acked[id] = true
+	}
+
+	ops := box.Ops[:0]
+	for _, op := range box.Ops {
+		if !acked[op.ID] {
+			ops = append(ops, op)
+		}
+	}
+
+	box.Ops = ops
+	return obr.write(box)
+}
+
+func (obr *outboxRepo) read() (*models.Outbox, error) {
+	data, err := os.ReadFile(obr.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &models.Outbox{}, nil
+	}
+
+	if err != nil {
+		appErr := apperrors.GetOutboxErr.AppendMessage(err)
+		obr.log.Error(appErr)
+		return nil, appErr
+	}
+
+	box := &models.Outbox{}
+	if err := json.Unmarshal(data, box); err != nil {
+		appErr := apperrors.GetOutboxErr.AppendMessage(err)
+		obr.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return box, nil
+}
+
+func (obr *outboxRepo) write(box *models.Outbox) error {
+	data, err := json.MarshalIndent(box, "", "   ")
+	if err != nil {
+		appErr := apperrors.SaveOutboxErr.AppendMessage(err)
+		obr.log.Error(appErr)
+		return appErr
+	}
+
+	if err := writeFileDurable(obr.path, data); err != nil {
+		appErr := apperrors.SaveOutboxErr.AppendMessage(err)
+		obr.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
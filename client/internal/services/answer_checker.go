@@ -0,0 +1,100 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/agnivade/levenshtein"
+)
+
+// The offline quiz grades like the server does, this file mirrors server/internal/services/answer_checker.go
+// and answer_checker_test.go runs the same table on both.
+
+// parenthetical matches the notes of a library field, "(informal)" or "[pl.]".
+var parenthetical = regexp.MustCompile(`\([^)]*\)|\[[^\]]*\]`)
+
+// leadingArticles are dropped from the start of a sense, "to run" and "run" are the same answer.
+var leadingArticles = map[string]bool{"to": true, "a": true, "an": true, "the": true}
+
+// checkAnswer grades an answer against the accepted fields, a field holds one or more comma separated senses.
+// Every sense of the answer must match a sense of a field, the case, the spaces, the notes and the articles don't count.
+// A sense within allowedTypos edits is right with a typo.
+func checkAnswer(answer string, accepted []string) (bool, bool) {
+	answerSenses := splitSenses(answer)
+	if len(answerSenses) == 0 {
+		return false, false
+	}
+
+	senses := []string{}
+	for _, expected := range accepted {
+		senses = append(senses, splitSenses(expected)...)
+	}
+
+	typo := false
+	for _, answerSense := range answerSenses {
+		exact, near := matchSense(answerSense, senses)
+		if !exact && !near {
+			return false, false
+		}
+
+		typo = typo || !exact
+	}
+
+	return true, typo
+}
+
+// matchSense tells whether the sense is one of the senses or a few typos away from one.
+func matchSense(sense string, senses []string) (bool, bool) {
+	near := false
+	for _, expected := range senses {
+		if expected == sense {
+			return true, false
+		}
+
+		near = near || levenshtein.ComputeDistance(expected, sense) <= allowedTypos(expected)
+	}
+
+	return false, near
+}
+
+// allowedTypos scales the tolerance with the length of the expected sense, short words must be exact.
+func allowedTypos(sense string) int {
+	switch length := utf8.RuneCountInString(sense); {
+	case length <= 3:
+		return 0
+	case length <= 6:
+		return 1
+	case length <= 10:
+		return 2
+	}
+
+	return 3
+}
+
+// splitSenses turns "(to) run, manage; operate" into "run", "manage" and "operate".
+func splitSenses(field string) []string {
+	field = parenthetical.ReplaceAllString(field, " ")
+	senses := []string{}
+	for _, sense := range strings.FieldsFunc(field, isSenseSeparator) {
+		if sense = normalizeSense(sense); sense != "" {
+			senses = append(senses, sense)
+		}
+	}
+
+	return senses
+}
+
+func isSenseSeparator(r rune) bool {
+	return r == ',' || r == ';' || r == '/'
+}
+
+// normalizeSense lower-cases the sense, drops its leading articles, its punctuation and its spaces.
+func normalizeSense(sense string) string {
+	words := strings.Fields(strings.ToLower(sense))
+	for len(words) > 1 && leadingArticles[words[0]] {
+		words = words[1:]
+	}
+
+	return strings.Trim(strings.Join(words, ""), ".!?\"'")
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckAnswer(t *testing.T) {
+	tests := []struct {
+		name      string
+		answer    string
+		accepted  []string
+		wantRight bool
+		wantTypo  bool
+	}{
+		{name: "exact", answer: "house", accepted: []string{"house"}, wantRight: true},
+		{name: "article and case", answer: "To Run", accepted: []string{"run, dash"}, wantRight: true},
+		{name: "any order of senses", answer: "dash, run", accepted: []string{"run, dash"}, wantRight: true},
+		{name: "sense of another field", answer: "sprint", accepted: []string{"run, dash", "sprint"}, wantRight: true},
+		{name: "typo", answer: "hause", accepted: []string{"house"}, wantRight: true, wantTypo: true},
+		{name: "typo in cyrillic", answer: "компютер", accepted: []string{"компьютер"}, wantRight: true, wantTypo: true},
+		{name: "short word must be exact", answer: "cot", accepted: []string{"cat"}},
+		{name: "one wrong sense", answer: "run, walk", accepted: []string{"run, dash"}},
+		{name: "empty", answer: " ", accepted: []string{"run"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			right, typo := checkAnswer(tt.answer, tt.accepted)
+			if right != tt.wantRight || typo != tt.wantTypo {
+				t.Errorf("checkAnswer(%q, %q) = %v, %v, want %v, %v", tt.answer, tt.accepted, right, typo, tt.wantRight, tt.wantTypo)
+			}
+		})
+	}
+}
+
+func TestSplitSenses(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		want  []string
+	}{
+		{name: "separators and notes", field: "Run, to go; (informal) dash/sprint", want: []string{"run", "go", "dash", "sprint"}},
+		{name: "spaces and punctuation", field: "the United States!", want: []string{"unitedstates"}},
+		{name: "article alone stays", field: "a", want: []string{"a"}},
+		{name: "only notes", field: "[pl.]", want: []string{}},
+		{name: "empty", field: "", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitSenses(tt.field); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSenses(%q) = %q, want %q", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowedTypos(t *testing.T) {
+	tests := []struct {
+		sense string
+		want  int
+	}{
+		{sense: "cat", want: 0},
+		{sense: "дом", want: 0},
+		{sense: "house", want: 1},
+		{sense: "молоко", want: 1},
+		{sense: "computer", want: 2},
+		{sense: "extraordinary", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sense, func(t *testing.T) {
+			if got := allowedTypos(tt.sense); got != tt.want {
+				t.Errorf("allowedTypos(%q) = %v, want %v", tt.sense, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,14 +2,21 @@ package services
 
 import (
 	"bufio"
+	"client/internal/apperrors"
+	"client/internal/clients"
 	"client/internal/domain/requests"
 	"client/internal/models"
+	"client/internal/repositories"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"math/rand"
 	"os"
 	"strings"
 	"time"
 
-	"github.com/agnivade/levenshtein"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -17,9 +24,18 @@ const (
 	yourName     = "Your Name"
 	tapLastName  = "Your last Name"
 	yourPassword = "Your Password"
-	tapRole      = "Your role"
 )
 
+// skipNotFound lets a session go on when the server no longer has the word.
+func skipNotFound(log *logrus.Logger, err error) error {
+	if errors.Is(err, &apperrors.NotFoundErr) {
+		log.Warn(err)
+		return nil
+	}
+
+	return err
+}
+
 func scanLine() (string, error) {
 	fmt.Print("       ...")
 	in := bufio.NewScanner(os.Stdin)
@@ -46,30 +62,14 @@ func printTime(duration time.Duration) {
 	fmt.Printf("Time: %d minutes %d seconds\n", minutes, seconds)
 }
 
-func compareStringsLevenshtein(quest, answer string) bool {
-	quest = strings.ToLower(quest)
-	answer = strings.ToLower(answer)
-	mistakes := 1
-	if distance := levenshtein.ComputeDistance(quest, answer); distance <= mistakes {
-		return true
-	}
-
-	return false
-
-}
-
-func ignorSpace(s string) (c string) {
-	for _, v := range s {
-		if v != ' ' {
-			c = c + string(v)
-		}
-	}
-
-	return
+// isRetyped tells whether the answer retyped after a mistake is right, it is graded like the quiz answers.
+func isRetyped(expected string, answer string) bool {
+	right, _ := checkAnswer(answer, []string{expected})
+	return right
 }
 
 func scanUser() *requests.CreateUserRequest {
-	var email, name, lastName, password, role string
+	var email, name, lastName, password string
 	fmt.Println(tapEmail)
 	fmt.Scan(&email)
 	fmt.Println(yourName)
@@ -78,13 +78,156 @@ func scanUser() *requests.CreateUserRequest {
 	fmt.Scan(&lastName)
 	fmt.Println(yourPassword)
 	fmt.Scan(&password)
-	fmt.Println(tapRole)
-	fmt.Scan(&role)
 	return &requests.CreateUserRequest{
 		Email:    email,
 		Name:     name,
 		LastName: lastName,
 		Password: password,
-		Role:     role,
 	}
 }
+
+// lookUp asks the library and keeps the answer in the cache, the kept one is shown while the server is unreachable.
+func lookUp(clientLibrary clients.LibraryClient, repoCache repositories.CacheRepo, log *logrus.Logger, word string) ([]*models.Library, error) {
+	key := strings.ToLower(strings.TrimSpace(word))
+	words, err := clientLibrary.GetTranslation(&requests.GetTranslationReq{Word: word})
+	if errors.Is(err, &apperrors.UnreachableErr) {
+		cache, cacheErr := repoCache.GetCache()
+		if cacheErr != nil {
+			return nil, err
+		}
+
+		cached, ok := cache.Translations[key]
+		if !ok {
+			return nil, err
+		}
+
+		return cached, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := repoCache.GetCache()
+	if err != nil {
+		log.Error(err)
+		return words, nil
+	}
+
+	cache.Translations[key] = words
+	if err := repoCache.SaveCache(cache); err != nil {
+		log.Error(err)
+	}
+
+	return words, nil
+}
+
+// pickOffline shuffles a copy of the cached list and takes quantity words, all of them when quantity isn't set.
+func pickOffline(words []*models.Word, quantity int) []*models.Word {
+	picked := append([]*models.Word{}, words...)
+	rand.Shuffle(len(picked), func(i, j int) { picked[i], picked[j] = picked[j], picked[i] })
+	if quantity > 0 && quantity < len(picked) {
+		picked = picked[:quantity]
+	}
+
+	return picked
+}
+
+// promptOffline asks the Russian word and expects the English one, reverse swaps them.
+func promptOffline(word *models.Word, reverse bool) (string, string) {
+	if reverse {
+		return word.English, word.Russian
+	}
+
+	return word.Russian, word.English
+}
+
+// acceptedOffline takes the answers of every cached word with the same prompt, the synonyms are right too.
+func acceptedOffline(cache *models.Cache, prompt string, expected string, reverse bool) []string {
+	accepted := []string{expected}
+	for _, word := range cachedWords(cache) {
+		if wordPrompt, wordExpected := promptOffline(word, reverse); wordPrompt == prompt {
+			accepted = append(accepted, wordExpected)
+		}
+	}
+
+	return accepted
+}
+
+// choicesOffline draws the wrong options of a multiple choice prompt from the cached lists.
+func choicesOffline(cache *models.Cache, expected string, reverse bool, quantity int) []string {
+	if quantity == 0 {
+		return nil
+	}
+
+	seen := map[string]bool{expected: true}
+	choices := []string{}
+	for _, word := range cachedWords(cache) {
+		if _, option := promptOffline(word, reverse); !seen[option] {
+			seen[option] = true
+			choices = append(choices, option)
+		}
+	}
+
+	rand.Shuffle(len(choices), func(i, j int) { choices[i], choices[j] = choices[j], choices[i] })
+	if len(choices) > quantity-1 {
+		choices = choices[:quantity-1]
+	}
+
+	choices = append(choices, expected)
+	rand.Shuffle(len(choices), func(i, j int) { choices[i], choices[j] = choices[j], choices[i] })
+	return choices
+}
+
+func cachedWords(cache *models.Cache) []*models.Word {
+	words := append([]*models.Word{}, cache.Words...)
+	words = append(words, cache.Learn...)
+	return append(words, cache.Learned...)
+}
+
+func addWord(words []*models.Word, word *models.Word) []*models.Word {
+	for _, w := range words {
+		if w.ID == word.ID {
+			return words
+		}
+	}
+
+	return append(words, word)
+}
+
+func removeWord(words []*models.Word, id string) []*models.Word {
+	kept := []*models.Word{}
+	for _, w := range words {
+		if w.ID != id {
+			kept = append(kept, w)
+		}
+	}
+
+	return kept
+}
+
+var wordChangeKinds = map[string]bool{models.OpMoveToLearned: true, models.OpAddToLearn: true, models.OpDeleteLearn: true}
+
+// wordChanges counts the word list changes at the head of the outbox, at most one batch.
+func wordChanges(ops []*models.OutboxOp) int {
+	n := 0
+	for n < len(ops) && n < maxWordChanges && wordChangeKinds[ops[n].Kind] {
+		n++
+	}
+
+	return n
+}
+
+// batchKey is the same for the same operations, a batch cut off and sent again is applied once.
+func batchKey(ops []*models.OutboxOp) string {
+	hash := sha256.New()
+	for _, op := range ops {
+		hash.Write([]byte(op.ID + "\n"))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func opName(op *models.OutboxOp) string {
+	return fmt.Sprintf("%v %v", op.Kind, op.ID)
+}
@@ -4,7 +4,9 @@ import (
 	"client/internal/apperrors"
 	"client/internal/clients"
 	"client/internal/domain/requests"
+	"client/internal/repositories"
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/sirupsen/logrus"
@@ -12,11 +14,12 @@ import (
 
 type LibraryService struct {
 	clientLibrary clients.LibraryClient
+	repoCache     repositories.CacheRepo
 	log           *logrus.Logger
 }
 
-func NewLibraryService(clientLibrary clients.LibraryClient, log *logrus.Logger) *LibraryService {
-	return &LibraryService{clientLibrary: clientLibrary, log: log}
+func NewLibraryService(clientLibrary clients.LibraryClient, repoCache repositories.CacheRepo, log *logrus.Logger) *LibraryService {
+	return &LibraryService{clientLibrary: clientLibrary, repoCache: repoCache, log: log}
 }
 
 func (sl *LibraryService) Translate(ctx context.Context) error {
@@ -33,8 +36,13 @@ func (sl *LibraryService) Translate(ctx context.Context) error {
 			break
 		}
 
-		wordRequest := &requests.GetTranslationReq{Word: word}
-		words, err := sl.clientLibrary.GetTranslation(wordRequest)
+		words, err := lookUp(sl.clientLibrary, sl.repoCache, sl.log, word)
+		if errors.Is(err, &apperrors.UnreachableErr) {
+			sl.log.Warn(err)
+			fmt.Println("The server is unreachable and the word isn't in the cache")
+			continue
+		}
+
 		if err != nil {
 			appErr := apperrors.TranslateErr.AppendMessage(err)
 			sl.log.Error(appErr)
@@ -48,3 +56,54 @@ func (sl *LibraryService) Translate(ctx context.Context) error {
 
 	return nil
 }
+
+// TypePhrases asks library phrases in Russian, the server checks the English typed.
+func (sl *LibraryService) TypePhrases(ctx context.Context, quantity int) error {
+	phrases, err := sl.clientLibrary.GetPhrases(quantity)
+	if err != nil {
+		appErr := apperrors.TypePhrasesErr.AppendMessage(err)
+		sl.log.Error(appErr)
+		return appErr
+	}
+
+	if len(phrases) == 0 {
+		fmt.Println("There aren't phrases to type")
+		return nil
+	}
+
+	fmt.Println("TYPE THE PHRASES")
+	var right int
+	var wrong int
+	for _, phrase := range phrases {
+		fmt.Println(phrase.Prompt)
+		answer, err := scanLine()
+		if err != nil {
+			appErr := apperrors.TypePhrasesErr.AppendMessage(err)
+			sl.log.Error(appErr)
+			return appErr
+		}
+
+		checkReq := &requests.CheckPhraseRequest{PhraseID: phrase.ID, Answer: answer}
+		verdict, err := sl.clientLibrary.CheckPhrase(checkReq)
+		if err != nil {
+			appErr := apperrors.TypePhrasesErr.AppendMessage(err)
+			sl.log.Error(appErr)
+			return appErr
+		}
+
+		if !verdict.Correct {
+			wrong++
+			fmt.Println(" ", verdict.Expected)
+			continue
+		}
+
+		right++
+		fmt.Println("Yes")
+		if verdict.Typo {
+			fmt.Println("Spelling mistake ", verdict.Expected)
+		}
+	}
+
+	fmt.Println(right, wrong)
+	return nil
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"client/internal/apperrors"
+	"client/internal/domain/requests"
+	"client/internal/mappers"
+	"client/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	// cacheLimit is how many words of every list the cache keeps, the most the server gives in one page.
+	cacheLimit = "100"
+	// maxWordChanges is the most operations the server takes in one batch.
+	maxWordChanges       = 100
+	wordOperationApplied = "applied"
+)
+
+// Sync replays the outbox and then caches the lists the server has, an unreachable server leaves both as they are.
+func (us *UserService) Sync(ctx context.Context, user *models.User) {
+	if err := us.SyncOutbox(ctx, user); err != nil {
+		us.log.Error(err)
+		return
+	}
+
+	err := us.RefreshCache(ctx, user)
+	if errors.Is(err, &apperrors.UnreachableErr) {
+		us.log.Warn(err)
+		return
+	}
+
+	if err != nil {
+		us.log.Error(err)
+	}
+}
+
+// SyncOutbox replays the operations made offline in their order. An unreachable server stops it quietly,
+// the rest stays in the outbox for the next time. The word list changes in a row, the ones of a session,
+// go in one batch with an Idempotency-Key made of their IDs, the server applies a replayed batch only once.
+func (us *UserService) SyncOutbox(ctx context.Context, user *models.User) error {
+	ops, err := us.repoOutbox.Pending()
+	if err != nil {
+		appErr := apperrors.SyncOutboxErr.AppendMessage(err)
+		us.log.Error(appErr)
+		return appErr
+	}
+
+	pending := []*models.OutboxOp{}
+	for _, op := range ops {
+		if op.UserID == user.ID {
+			pending = append(pending, op)
+		}
+	}
+
+	for len(pending) > 0 {
+		done := pending[:wordChanges(pending)]
+		var err error
+		if len(done) > 0 {
+			err = us.replayWords(done)
+		} else {
+			done = pending[:1]
+			err = us.replay(done[0])
+		}
+
+		if errors.Is(err, &apperrors.UnreachableErr) {
+			us.log.Warn(err)
+			return nil
+		}
+
+		if err != nil {
+			appErr := apperrors.SyncOutboxErr.AppendMessage(err)
+			us.log.Error(appErr)
+			return appErr
+		}
+
+		ids := make([]string, 0, len(done))
+		for _, op := range done {
+			ids = append(ids, op.ID)
+		}
+
+		if err := us.repoOutbox.Ack(ids...); err != nil {
+			appErr := apperrors.SyncOutboxErr.AppendMessage(err)
+			us.log.Error(appErr)
+			return appErr
+		}
+
+		us.log.Infof("%v offline operations have been synced", len(done))
+		pending = pending[len(done):]
+	}
+
+	return nil
+}
+
+// replayWords sends word list changes in one batch, the server reports a word it doesn't know and skips it.
+func (us *UserService) replayWords(ops []*models.OutboxOp) error {
+	batchReq := &requests.WordsBatchRequest{UserID: ops[0].UserID, IdempotencyKey: batchKey(ops)}
+	for _, op := range ops {
+		batchReq.Operations = append(batchReq.Operations, &requests.WordOperationRequest{WordID: op.WordID, Action: op.Kind})
+	}
+
+	batchResp, err := us.clientUser.ApplyWordOperations(batchReq)
+	if err != nil {
+		return us.replayed(fmt.Sprintf("batch %v", batchReq.IdempotencyKey), err)
+	}
+
+	for _, result := range batchResp.Results {
+		if result.Status != wordOperationApplied {
+			us.log.Warnf("the offline %v of the word %v is %v on the server", result.Action, result.WordID, result.Status)
+		}
+	}
+
+	return nil
+}
+
+func (us *UserService) replay(op *models.OutboxOp) error {
+	if op.Kind == models.OpQuizSession {
+		return us.replaySession(op)
+	}
+
+	us.log.Warnf("the unknown outbox operation %v %v is dropped", op.Kind, op.ID)
+	return nil
+}
+
+// replaySession reports a quiz played offline, ServerID and Recorded are saved after every call
+// so a replay cut off goes on from where it stopped. The start and every answer carry an Idempotency-Key
+// made of the operation ID, a call whose response was lost isn't counted twice.
+func (us *UserService) replaySession(op *models.OutboxOp) error {
+	session := op.Session
+	if session.ServerID == "" {
+		startReq := &requests.StartQuizSessionRequest{
+			Mode:           session.Mode,
+			Direction:      session.Direction,
+			StartedAt:      session.StartedAt,
+			IdempotencyKey: op.ID + ":start",
+		}
+		started, err := us.clientUser.StartQuizSession(startReq)
+		if err != nil {
+			return us.replayed(opName(op), err)
+		}
+
+		session.ServerID = started.ID
+		if err := us.repoOutbox.Update(op); err != nil {
+			return err
+		}
+	}
+
+	for session.Recorded < len(session.Answers) {
+		answer := session.Answers[session.Recorded]
+		answerReq := &requests.QuizAnswerRequest{
+			SessionID:      session.ServerID,
+			WordID:         answer.WordID,
+			Correct:        answer.Correct,
+			Typo:           answer.Typo,
+			LatencyMs:      answer.LatencyMs,
+			AnsweredAt:     answer.AnsweredAt,
+			IdempotencyKey: fmt.Sprintf("%v:answer:%v", op.ID, session.Recorded),
+		}
+		if err := us.replayed(opName(op), us.clientUser.RecordQuizAnswer(answerReq)); err != nil {
+			return err
+		}
+
+		session.Recorded++
+		if err := us.repoOutbox.Update(op); err != nil {
+			return err
+		}
+	}
+
+	finishReq := &requests.FinishQuizSessionRequest{SessionID: session.ServerID, FinishedAt: session.FinishedAt}
+	_, err := us.clientUser.FinishQuizSession(finishReq)
+	return us.replayed(opName(op), err)
+}
+
+// replayed keeps the error of a call the server may take later, InProgressErr too. A call the server answers
+// with 400, 404 or 409 will never go through, it is dropped.
+func (us *UserService) replayed(name string, err error) error {
+	if errors.Is(err, &apperrors.BadRequestErr) || errors.Is(err, &apperrors.NotFoundErr) || errors.Is(err, &apperrors.ConflictErr) {
+		us.log.Warnf("the offline %v is rejected by the server and dropped: %v", name, err)
+		return nil
+	}
+
+	return err
+}
+
+// RefreshCache keeps the words, the learn and the learned lists of the user, the translations stay.
+func (us *UserService) RefreshCache(ctx context.Context, user *models.User) error {
+	getReq := &requests.GetWordsByUsIdAndLimitRequest{ID: user.ID, Limit: cacheLimit}
+	words, err := us.clientUser.GetUserWithWordsByIDLimit(getReq)
+	if err != nil {
+		return apperrors.RefreshCacheErr.AppendMessage(err)
+	}
+
+	learn, err := us.clientUser.GetUserWithLearnByIDLimit(getReq)
+	if err != nil {
+		return apperrors.RefreshCacheErr.AppendMessage(err)
+	}
+
+	learned, err := us.clientUser.GetUserWithLearnedByIDLimit(getReq)
+	if err != nil {
+		return apperrors.RefreshCacheErr.AppendMessage(err)
+	}
+
+	cache, err := us.repoCache.GetCache()
+	if err != nil {
+		return apperrors.RefreshCacheErr.AppendMessage(err)
+	}
+
+	cache.UserID = user.ID
+	cache.Words = mappers.MapWordRespToWords(words)
+	cache.Learn = mappers.MapWordRespToWords(learn)
+	cache.Learned = mappers.MapWordRespToWords(learned)
+	cache.UpdatedAt = time.Now()
+	if err := us.repoCache.SaveCache(cache); err != nil {
+		return apperrors.RefreshCacheErr.AppendMessage(err)
+	}
+
+	return nil
+}
+
+// playOffline runs a test or a learn session on the cached lists and grades it here.
+// Every word list change goes to the outbox at once, the session goes there when it is over.
+func (us *UserService) playOffline(user *models.User, quizMode string, quantity int, mode QuizMode, retype bool) error {
+	cache, err := us.repoCache.GetCache()
+	if err != nil {
+		return err
+	}
+
+	words := cache.Words
+	if quizMode == quizModeLearn {
+		words = cache.Learn
+	}
+
+	if cache.UserID != user.ID || len(words) == 0 {
+		fmt.Println("There aren't cached words to play offline")
+		return nil
+	}
+
+	queue := pickOffline(words, quantity)
+	session := &models.OfflineSession{Mode: quizMode, Direction: mode.Direction, StartedAt: time.Now()}
+	var right, wrong, typos int
+	for len(queue) > 0 {
+		word := queue[0]
+		queue = queue[1:]
+
+		reverse := mode.Direction == quizDirectionReverse || mode.Direction == quizDirectionMix && rand.Intn(2) == 1
+		prompt, expected := promptOffline(word, reverse)
+		choices := choicesOffline(cache, expected, reverse, mode.Choices)
+		fmt.Println(prompt)
+		printChoices(choices)
+		promptedAt := time.Now()
+		answer, err := scanLine()
+		if err != nil {
+			return err
+		}
+
+		answer = pickChoice(choices, answer)
+		correct, typo := checkAnswer(answer, acceptedOffline(cache, prompt, expected, reverse))
+		session.Answers = append(session.Answers, &models.OfflineAnswer{
+			WordID:     word.ID,
+			Correct:    correct,
+			Typo:       typo,
+			LatencyMs:  time.Since(promptedAt).Milliseconds(),
+			AnsweredAt: time.Now(),
+		})
+
+		if err := us.applyOffline(cache, user, quizMode, word, correct); err != nil {
+			return err
+		}
+
+		if correct {
+			right++
+			fmt.Println("Yes")
+			if typo {
+				typos++
+				fmt.Println("Spelling mistake ", expected)
+			}
+
+			continue
+		}
+
+		wrong++
+		if quizMode == quizModeLearn {
+			queue = append(queue, word)
+		}
+
+		us.printTranslations(expected)
+		for retype && len(choices) == 0 {
+			answer, err := scanLine()
+			if err != nil {
+				return err
+			}
+
+			if isRetyped(expected, answer) {
+				break
+			}
+		}
+	}
+
+	session.FinishedAt = time.Now()
+	if err := us.repoOutbox.Append(&models.OutboxOp{Kind: models.OpQuizSession, UserID: user.ID, Session: session}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Right %d, wrong %d, typos %d\n", right, wrong, typos)
+	return nil
+}
+
+// applyOffline queues the change the server would make for the answer and makes it in the cache too.
+func (us *UserService) applyOffline(cache *models.Cache, user *models.User, quizMode string, word *models.Word, correct bool) error {
+	var kind string
+	switch {
+	case quizMode == quizModeTest && correct:
+		kind = models.OpMoveToLearned
+		cache.Words = removeWord(cache.Words, word.ID)
+		cache.Learned = addWord(cache.Learned, word)
+	case quizMode == quizModeTest:
+		kind = models.OpAddToLearn
+		cache.Learn = addWord(cache.Learn, word)
+	case correct:
+		kind = models.OpDeleteLearn
+		cache.Learn = removeWord(cache.Learn, word.ID)
+	default:
+		return nil
+	}
+
+	if err := us.repoOutbox.Append(&models.OutboxOp{Kind: kind, UserID: user.ID, WordID: word.ID}); err != nil {
+		return err
+	}
+
+	return us.repoCache.SaveCache(cache)
+}
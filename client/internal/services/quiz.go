@@ -0,0 +1,122 @@
+package services
+
+import (
+	"client/internal/domain/requests"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	quizModeTest         = "test"
+	quizModeLearn        = "learn"
+	quizDirectionForward = "forward"
+	quizDirectionReverse = "reverse"
+	quizDirectionMix     = "mix"
+	quizChoices          = 4
+)
+
+// QuizMode is how a test or a learn session asks the words, Choices turns every prompt into a multiple choice.
+type QuizMode struct {
+	Direction string
+	Choices   int
+}
+
+var (
+	QuizRussianEnglish = QuizMode{Direction: quizDirectionForward}
+	QuizEnglishRussian = QuizMode{Direction: quizDirectionReverse}
+	QuizMix            = QuizMode{Direction: quizDirectionMix}
+	QuizMultipleChoice = QuizMode{Direction: quizDirectionMix, Choices: quizChoices}
+)
+
+// playQuiz asks the prompts of a session until the server has nothing left, the server grades every answer.
+// After a wrong answer the translations are shown, retype makes the user type the right answer before going on.
+// A multiple choice prompt is answered with the number of the option.
+func (us *UserService) playQuiz(sessionID string, retype bool) error {
+	for {
+		prompt, err := us.clientUser.NextQuizPrompt(sessionID)
+		if err != nil {
+			return err
+		}
+
+		if prompt.Done {
+			return nil
+		}
+
+		fmt.Println(prompt.Prompt)
+		printChoices(prompt.Choices)
+		answer, err := scanLine()
+		if err != nil {
+			return err
+		}
+
+		answer = pickChoice(prompt.Choices, answer)
+
+		checkReq := &requests.CheckQuizAnswerRequest{SessionID: sessionID, Position: prompt.Position, Answer: answer}
+		verdict, err := us.clientUser.CheckQuizAnswer(checkReq)
+		if err != nil {
+			return err
+		}
+
+		if verdict.Correct {
+			fmt.Println("Yes")
+			if verdict.Typo {
+				fmt.Println("Spelling mistake ", verdict.Expected)
+			}
+
+			continue
+		}
+
+		us.printTranslations(verdict.Expected)
+		for retype && len(prompt.Choices) == 0 {
+			answer, err := scanLine()
+			if err != nil {
+				return err
+			}
+
+			if isRetyped(verdict.Expected, answer) {
+				break
+			}
+		}
+	}
+}
+
+func printChoices(choices []string) {
+	for i, choice := range choices {
+		fmt.Printf("   %d) %v\n", i+1, choice)
+	}
+}
+
+// pickChoice turns the number of an option into its text, any other answer is sent as typed.
+func pickChoice(choices []string, answer string) string {
+	number, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || number < 1 || number > len(choices) {
+		return answer
+	}
+
+	return choices[number-1]
+}
+
+// printTranslations shows the library entries of the expected answer, or the answer alone when the library doesn't know it.
+func (us *UserService) printTranslations(expected string) {
+	lib, err := lookUp(us.clientLibrary, us.repoCache, us.log, expected)
+	if err != nil || len(lib) == 0 {
+		us.log.Warn(err)
+		fmt.Println(expected)
+		return
+	}
+
+	printAll(lib)
+}
+
+func (us *UserService) finishQuiz(sessionID string) error {
+	finishReq := &requests.FinishQuizSessionRequest{SessionID: sessionID, FinishedAt: time.Now()}
+	summary, err := us.clientUser.FinishQuizSession(finishReq)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Right %d, wrong %d, typos %d\n", summary.Right, summary.Wrong, summary.Typos)
+	return nil
+}
@@ -4,13 +4,14 @@ import (
 	"client/internal/apperrors"
 	"client/internal/clients"
 	"client/internal/domain/requests"
+	"client/internal/domain/responses"
 	"client/internal/mappers"
 	"client/internal/models"
 	"client/internal/repositories"
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
-	"strings"
+	"os"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -20,12 +21,21 @@ type UserService struct {
 	clientUser    clients.UserClient
 	clientLibrary clients.LibraryClient
 	repoBackup    repositories.BackupRepo
+	repoCache     repositories.CacheRepo
+	repoOutbox    repositories.OutboxRepo
 	log           *logrus.Logger
 }
 
-func NewUserService(clientUser clients.UserClient, clientLibrary clients.LibraryClient,
-	repoBackup repositories.BackupRepo, log *logrus.Logger) *UserService {
-	return &UserService{clientUser: clientUser, repoBackup: repoBackup, clientLibrary: clientLibrary, log: log}
+func NewUserService(clientUser clients.UserClient, clientLibrary clients.LibraryClient, repoBackup repositories.BackupRepo,
+	repoCache repositories.CacheRepo, repoOutbox repositories.OutboxRepo, log *logrus.Logger) *UserService {
+	return &UserService{
+		clientUser:    clientUser,
+		repoBackup:    repoBackup,
+		repoCache:     repoCache,
+		repoOutbox:    repoOutbox,
+		clientLibrary: clientLibrary,
+		log:           log,
+	}
 }
 
 func (us *UserService) UserExistsOrRegistration(ctx context.Context) (*models.User, error) {
@@ -38,249 +48,240 @@ func (us *UserService) UserExistsOrRegistration(ctx context.Context) (*models.Us
 	user := &models.User{}
 	user = userFromBackup
 
-	if user.ID == "" {
+	for user.ID == "" {
 		createUsReq := scanUser()
 		user = mappers.MapCreateUserReqToUser(createUsReq)
 		userId, err := us.clientUser.CreateUser(createUsReq)
-		if err != nil {
+		if errors.Is(err, &apperrors.ConflictErr) {
 			us.log.Error(err)
-			return nil, err
+			fmt.Println("the email is already registered")
+			continue
 		}
 
-		user.ID = userId.UserId
-	}
-
-	time.Sleep(time.Millisecond * 15)
-	for {
-		fmt.Printf("[%v] Enter your password", user.Name)
-		pass, err := scanLine()
-		if err != nil {
+		if errors.Is(err, &apperrors.BadRequestErr) {
 			us.log.Error(err)
+			fmt.Println("the registration is rejected, check the email and the password (8+ characters with a letter and a digit)")
+			continue
 		}
 
-		loginUsReq := &requests.LoginRequest{Email: user.Email, Password: pass}
-		tokenReq, err := us.clientUser.Login(loginUsReq)
 		if err != nil {
 			us.log.Error(err)
-			fmt.Println("wrong password")
-			continue
+			return nil, err
 		}
 
-		user.Token = tokenReq.Token
-		user.TokenExpired = tokenReq.ExpiresIn
-		break
+		user.ID = userId.UserId
+	}
+
+	time.Sleep(time.Millisecond * 15)
+	tokenResp, err := us.refreshSession(user)
+	if errors.Is(err, &apperrors.UnreachableErr) {
+		return us.offlineUser(user), nil
 	}
 
-	us.log.Infof("LOGIN_CLIENT success %+v", user.Token)
+	if err != nil && !errors.Is(err, &apperrors.UnauthorizedErr) && !errors.Is(err, &apperrors.RefreshSessionErr) {
+		us.log.Error(err)
+		return nil, err
+	}
 
-	userForBackup := *user
-	userForBackup.Password = ""
-	err = us.repoBackup.SaveUser(&userForBackup)
 	if err != nil {
 		us.log.Error(err)
+		tokenResp = us.loginWithPassword(user)
 	}
 
+	if tokenResp == nil {
+		return us.offlineUser(user), nil
+	}
+
+	us.applyTokens(user, tokenResp)
+	us.log.Info("LOGIN_CLIENT success")
+	us.clientUser.OnTokensRefreshed(func(loginResp *responses.LoginResponse) {
+		us.applyTokens(user, loginResp)
+	})
+
+	us.Sync(ctx, user)
 	us.log.Info("UserExistsOrRegistration invoked success")
 	return user, nil
 }
 
-func (c *UserService) TestWords(ctx context.Context, user *models.User, quantity int) error {
-	startTime := time.Now()
-	limit := strconv.Itoa(quantity)
-	getWordsReq := &requests.GetWordsByUsIdAndLimitRequest{ID: user.ID, Limit: limit}
-	testTable, err := c.clientUser.GetUserWithWordsByIDLimit(getWordsReq, user.Token)
-	if err != nil {
-		c.log.Error(err)
-		return err
-	}
+// offlineUser goes on with the user of the backup, the saved tokens are used again once the server is back.
+func (us *UserService) offlineUser(user *models.User) *models.User {
+	fmt.Println("The server is unreachable, working offline")
+	us.clientUser.SetTokens(user.Token, user.RefreshToken)
+	us.clientUser.OnTokensRefreshed(func(loginResp *responses.LoginResponse) {
+		us.applyTokens(user, loginResp)
+	})
 
-	c.log.Info(len(testTable))
+	us.log.Warn("UserExistsOrRegistration goes on offline")
+	return user
+}
 
-	if len(testTable) == 0 {
-		fmt.Println("There aren't what to test")
-		return nil
+// refreshSession signs in with the refresh token saved in the backup, so the password isn't asked again.
+func (us *UserService) refreshSession(user *models.User) (*responses.LoginResponse, error) {
+	if user.RefreshToken == "" {
+		return nil, apperrors.RefreshSessionErr.AppendMessage("there is no refresh token")
 	}
 
-	fmt.Println("                     START")
-	var right int
-	var wrong int
-	fmt.Println("TEST WORDS")
+	return us.clientUser.RefreshToken(&requests.RefreshTokenRequest{RefreshToken: user.RefreshToken})
+}
+
+// loginWithPassword asks the password until the login goes through, nil means the server is unreachable.
+func (us *UserService) loginWithPassword(user *models.User) *responses.LoginResponse {
+	device, err := os.Hostname()
+	if err != nil {
+		us.log.Error(err)
+	}
 
 	for {
-		word := testTable[0]
-		fmt.Println(word.Russian)
-		englishAnswer, err := scanLine()
+		fmt.Printf("[%v] Enter your password", user.Name)
+		pass, err := scanLine()
 		if err != nil {
-			appErr := apperrors.TestWordsErr.AppendMessage(err)
-			c.log.Error(appErr)
-			return appErr
+			us.log.Error(err)
 		}
-		englishAnswerIgnoreSpace := ignorSpace(englishAnswer)
-		englishWordQuest := ignorSpace(word.English)
-
-		if strings.EqualFold(englishWordQuest, englishAnswerIgnoreSpace) {
-			right++
-			fmt.Println("Yes")
-			moveToLearnedReq := &requests.MoveWordToLearnedRequest{WordID: word.ID, UserID: user.ID}
-			err := c.clientUser.MoveWordToLearned(moveToLearnedReq, user.Token)
-			if err != nil {
-				c.log.Error(err)
-				return err
-			}
-
-			if len(testTable) == 1 {
-				break
-			}
-
-			testTable = testTable[1:]
+
+		loginUsReq := &requests.LoginRequest{Email: user.Email, Password: pass, Device: device}
+		tokenResp, err := us.clientUser.Login(loginUsReq)
+		if errors.Is(err, &apperrors.UnreachableErr) {
+			us.log.Error(err)
+			return nil
+		}
+
+		if errors.Is(err, &apperrors.UnauthorizedErr) {
+			us.log.Error(err)
+			fmt.Println("wrong password")
 			continue
 		}
 
-		if compareStringsLevenshtein(englishWordQuest, englishAnswerIgnoreSpace) {
-			right++
-			fmt.Println("Yes")
-			fmt.Println("Spelling mistake ", word.English)
-			moveToLearnedReq := &requests.MoveWordToLearnedRequest{WordID: word.ID, UserID: user.ID}
-			err := c.clientUser.MoveWordToLearned(moveToLearnedReq, user.Token)
-			if err != nil {
-				c.log.Error(err)
-				return err
-			}
-
-			if len(testTable) == 1 {
-				break
-			}
-
-			testTable = testTable[1:]
+		if errors.Is(err, &apperrors.TooManyRequestsErr) {
+			us.log.Error(err)
+			fmt.Println("too many attempts, wait a bit and try again")
 			continue
 		}
 
-		wrong++
-		getTranslReq := &requests.GetTranslationReq{Word: word.English}
-		lib, err := c.clientLibrary.GetTranslation(getTranslReq)
 		if err != nil {
-			c.log.Error(err)
-			return err
+			us.log.Error(err)
+			fmt.Println("can't login, try again")
+			continue
 		}
 
-		printAll(lib)
-		for {
-			englishAnswer, err := scanLine()
-			if err != nil {
-				appErr := apperrors.TestWordsErr.AppendMessage(err)
-				c.log.Error(appErr)
-				return appErr
-			}
-
-			englishAnswerIgnoreSpace := ignorSpace(englishAnswer)
-			if strings.EqualFold(englishWordQuest, englishAnswerIgnoreSpace) {
-				break
-			}
-		}
+		return tokenResp
+	}
+}
 
-		moveToLearnedReq := &requests.MoveWordToLearnedRequest{WordID: word.ID, UserID: user.ID}
-		err = c.clientUser.AddWordToLearn(moveToLearnedReq, user.Token)
+// applyTokens hands the tokens to the client and keeps the refresh token in the backup.
+func (us *UserService) applyTokens(user *models.User, tokenResp *responses.LoginResponse) {
+	user.Token = tokenResp.Token
+	user.TokenExpired = tokenResp.ExpiresIn
+	user.RefreshToken = tokenResp.RefreshToken
+	us.clientUser.SetTokens(tokenResp.Token, tokenResp.RefreshToken)
+
+	userForBackup := *user
+	userForBackup.Password = ""
+	err := us.repoBackup.SaveUser(&userForBackup)
+	if err != nil {
+		us.log.Error(err)
+	}
+}
+
+func (c *UserService) TestWords(ctx context.Context, user *models.User, quantity int, mode QuizMode) error {
+	c.Sync(ctx, user)
+	startTime := time.Now()
+	startReq := &requests.StartQuizSessionRequest{Mode: quizModeTest, Size: quantity, Direction: mode.Direction, Choices: mode.Choices, StartedAt: startTime}
+	session, err := c.clientUser.StartQuizSession(startReq)
+	if errors.Is(err, &apperrors.UnreachableErr) {
+		fmt.Println("The server is unreachable, the test goes on offline")
+		err = c.playOffline(user, quizModeTest, quantity, mode, true)
 		if err != nil {
-			return err
+			appErr := apperrors.TestWordsErr.AppendMessage(err)
+			c.log.Error(appErr)
+			return appErr
 		}
 
-		if len(testTable) == 1 {
-			break
-		}
+		c.Sync(ctx, user)
 
-		testTable = testTable[1:]
+		printTime(time.Since(startTime))
+		return nil
 	}
 
-	duration := time.Since(startTime)
-	printTime(duration)
-	fmt.Println(right, wrong)
-
-	return nil
-}
+	if errors.Is(err, &apperrors.NotFoundErr) {
+		fmt.Println("There aren't what to test")
+		return nil
+	}
 
-func (us *UserService) LearnWords(ctx context.Context, quantity int, user *models.User) error {
-	startTime := time.Now()
-	limit := strconv.Itoa(quantity)
-	getWordsReq := &requests.GetWordsByUsIdAndLimitRequest{ID: user.ID, Limit: limit}
-	testTable, err := us.clientUser.GetUserWithLearnByIDLimit(getWordsReq, user.Token)
 	if err != nil {
-		us.log.Error(err)
+		c.log.Error(err)
 		return err
 	}
 
-	if len(testTable) == 0 {
-		fmt.Println("There isn't what to learn")
-		return nil
+	fmt.Println("                     START")
+	fmt.Println("TEST WORDS")
+	err = c.playQuiz(session.ID, true)
+	if err != nil {
+		appErr := apperrors.TestWordsErr.AppendMessage(err)
+		c.log.Error(appErr)
+		return appErr
 	}
 
-	fmt.Println("                 START")
-	fmt.Println("LEARN WORDS")
+	err = c.finishQuiz(session.ID)
+	if err != nil {
+		c.log.Error(err)
+		return err
+	}
 
-	for {
-		word := testTable[0]
-		fmt.Println(word.Russian)
-		englishAnswer, err := scanLine()
+	c.Sync(ctx, user)
+
+	duration := time.Since(startTime)
+	printTime(duration)
+
+	return nil
+}
+
+func (us *UserService) LearnWords(ctx context.Context, quantity int, user *models.User, mode QuizMode) error {
+	us.Sync(ctx, user)
+	startTime := time.Now()
+	startReq := &requests.StartQuizSessionRequest{Mode: quizModeLearn, Size: quantity, Direction: mode.Direction, Choices: mode.Choices, StartedAt: startTime}
+	session, err := us.clientUser.StartQuizSession(startReq)
+	if errors.Is(err, &apperrors.UnreachableErr) {
+		fmt.Println("The server is unreachable, the lesson goes on offline")
+		err = us.playOffline(user, quizModeLearn, quantity, mode, false)
 		if err != nil {
 			appErr := apperrors.LearnWordsErr.AppendMessage(err)
 			us.log.Error(appErr)
 			return appErr
 		}
 
-		englishAnswerIgnoreSpace := ignorSpace(englishAnswer)
-		englishWordQust := ignorSpace(word.English)
-
-		if strings.EqualFold(englishWordQust, englishAnswerIgnoreSpace) {
-			fmt.Println("Yes")
-			deleteLearnReq := &requests.DeleteLearnFromUserByIDRequest{UserID: user.ID, WordID: word.ID}
-			err := us.clientUser.DeleteLearnWordFromUserByWord(deleteLearnReq, user.Token)
-			if err != nil {
-				us.log.Error(err)
-				return err
-			}
+		us.Sync(ctx, user)
 
-			if len(testTable) == 1 {
-				break
-			}
-
-			testTable = testTable[1:]
-			continue
-		}
-
-		if compareStringsLevenshtein(englishWordQust, englishAnswerIgnoreSpace) {
-			fmt.Println("Yes")
-			fmt.Println("Spelling mistake ", word.English)
-			deleteLearnReq := &requests.DeleteLearnFromUserByIDRequest{UserID: user.ID, WordID: word.ID}
-			err := us.clientUser.DeleteLearnWordFromUserByWord(deleteLearnReq, user.Token)
-			if err != nil {
-				us.log.Error(err)
-				return err
-			}
-
-			if len(testTable) == 1 {
-				break
-			}
-
-			testTable = testTable[1:]
-			continue
-		}
+		printTime(time.Since(startTime))
+		return nil
+	}
 
-		getTranslReq := &requests.GetTranslationReq{Word: word.English}
-		lib, err := us.clientLibrary.GetTranslation(getTranslReq)
-		if err == nil {
-			us.log.Error(err)
-			return err
-		}
+	if errors.Is(err, &apperrors.NotFoundErr) {
+		fmt.Println("There isn't what to learn")
+		return nil
+	}
 
-		printAll(lib)
-		if len(testTable) == 1 {
-			break
-		}
+	if err != nil {
+		us.log.Error(err)
+		return err
+	}
 
-		testTable = append(testTable, word)
-		testTable = testTable[1:]
+	fmt.Println("                 START")
+	fmt.Println("LEARN WORDS")
+	err = us.playQuiz(session.ID, false)
+	if err != nil {
+		appErr := apperrors.LearnWordsErr.AppendMessage(err)
+		us.log.Error(appErr)
+		return appErr
+	}
 
+	err = us.finishQuiz(session.ID)
+	if err != nil {
+		us.log.Error(err)
+		return err
 	}
 
+	us.Sync(ctx, user)
+
 	duration := time.Since(startTime)
 	printTime(duration)
 	return nil
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"os"
 	"server/internal/server"
 	"time"
 )
@@ -8,5 +9,10 @@ import (
 func main() {
 
 	time.Sleep(3 * time.Second)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		server.Migrate(os.Args[2:])
+		return
+	}
+
 	server.Run()
 }
@@ -1,25 +1,158 @@
 package apperrors
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
+// AppError carries a stable Code for the clients and the HTTPCode the handlers answer with.
+// Err is the wrapped cause, so errors.Is and errors.As see through an AppError.
 type AppError struct {
 	Message  string
 	Code     string
 	HTTPCode int
+	Err      error
+	origin   *AppError
 }
 
 func NewAppError() *AppError {
 	return &AppError{}
 }
 
+// FieldError is a failed check of one request field, Field is its JSON path.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// FieldErrors is the cause of ValidationErr, errors.As finds it through the handler errors.
+type FieldErrors []*FieldError
+
+func (fieldErrs FieldErrors) Error() string {
+	messages := make([]string, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		messages = append(messages, fieldErr.Field+" "+fieldErr.Message)
+	}
+
+	return strings.Join(messages, ", ")
+}
+
 var (
+	BadRequestErr = AppError{
+		Message:  "Failed to BadRequestErr, the request is malformed",
+		Code:     badRequest,
+		HTTPCode: http.StatusBadRequest,
+	}
+	ConflictErr = AppError{
+		Message:  "Failed to ConflictErr, the request conflicts with the current state",
+		Code:     conflict,
+		HTTPCode: http.StatusConflict,
+	}
+	ValidationErr = AppError{
+		Message:  "Failed to ValidationErr",
+		Code:     validationFailed,
+		HTTPCode: http.StatusBadRequest,
+	}
+	InvalidCredentialsErr = AppError{
+		Message:  "Failed to InvalidCredentialsErr, wrong email or password",
+		Code:     invalidCredentials,
+		HTTPCode: http.StatusUnauthorized,
+	}
+	UserNotFoundErr = AppError{
+		Message:  "Failed to UserNotFoundErr",
+		Code:     userNotFound,
+		HTTPCode: http.StatusNotFound,
+	}
+	WordNotFoundErr = AppError{
+		Message:  "Failed to WordNotFoundErr",
+		Code:     wordNotFound,
+		HTTPCode: http.StatusNotFound,
+	}
+	EmailTakenErr = AppError{
+		Message:  "Failed to EmailTakenErr, the email is already registered",
+		Code:     emailTaken,
+		HTTPCode: http.StatusConflict,
+	}
+	QuizSessionNotFoundErr = AppError{
+		Message:  "Failed to QuizSessionNotFoundErr",
+		Code:     quizSessionNotFound,
+		HTTPCode: http.StatusNotFound,
+	}
+	QuizSessionFinishedErr = AppError{
+		Message:  "Failed to QuizSessionFinishedErr, the session is already finished",
+		Code:     quizSessionFinished,
+		HTTPCode: http.StatusConflict,
+	}
+	QuizSessionGradedErr = AppError{
+		Message:  "Failed to QuizSessionGradedErr, the server grades the answers of the session",
+		Code:     quizSessionGraded,
+		HTTPCode: http.StatusConflict,
+	}
+	QuizPromptMismatchErr = AppError{
+		Message:  "Failed to QuizPromptMismatchErr, the prompt is already answered or not asked yet",
+		Code:     quizPromptMismatch,
+		HTTPCode: http.StatusConflict,
+	}
+	QuizNoWordsErr = AppError{
+		Message:  "Failed to QuizNoWordsErr, there are no words for the session",
+		Code:     quizNoWords,
+		HTTPCode: http.StatusNotFound,
+	}
+	IdempotencyKeyErr = AppError{
+		Message:  "Failed to IdempotencyKeyErr, the key has to be 1 to 255 characters",
+		Code:     idempotencyKeyInvalid,
+		HTTPCode: http.StatusBadRequest,
+	}
+	IdempotencyKeyReusedErr = AppError{
+		Message:  "Failed to IdempotencyKeyReusedErr, the key has been used for another request",
+		Code:     idempotencyKeyReused,
+		HTTPCode: http.StatusUnprocessableEntity,
+	}
+	IdempotencyKeyInProgressErr = AppError{
+		Message:  "Failed to IdempotencyKeyInProgressErr, the first request with the key is still running",
+		Code:     idempotencyKeyInProgress,
+		HTTPCode: http.StatusConflict,
+	}
+	RequestTooLargeErr = AppError{
+		Message:  "Failed to RequestTooLargeErr, the body is too large",
+		Code:     requestTooLarge,
+		HTTPCode: http.StatusRequestEntityTooLarge,
+	}
+	PhraseNotFoundErr = AppError{
+		Message:  "Failed to PhraseNotFoundErr",
+		Code:     phraseNotFound,
+		HTTPCode: http.StatusNotFound,
+	}
 	SetupDatabaseErr = AppError{
 		Message: "Failed SetupDatabaseErr",
 		Code:    database,
 	}
+	PingDatabaseErr = AppError{
+		Message: "Failed PingDatabaseErr",
+		Code:    database,
+	}
+	CloseDatabaseErr = AppError{
+		Message: "Failed CloseDatabaseErr",
+		Code:    database,
+	}
+	LoadMigrationsErr = AppError{
+		Message: "Failed to LoadMigrationsErr",
+		Code:    migrations,
+	}
+	MigrateUpErr = AppError{
+		Message: "Failed to MigrateUpErr",
+		Code:    migrations,
+	}
+	MigrateDownErr = AppError{
+		Message: "Failed to MigrateDownErr",
+		Code:    migrations,
+	}
+	MigrateStatusErr = AppError{
+		Message: "Failed to MigrateStatusErr",
+		Code:    migrations,
+	}
 	EnvConfigLoadError = AppError{
 		Message: "Failed to load env file",
 		Code:    envInit,
@@ -42,9 +175,37 @@ var (
 	}
 	JWTMiddleware = AppError{
 		Message:  "Failed to JWTMiddlewareErr",
-		Code:     middleware,
+		Code:     unauthorized,
 		HTTPCode: http.StatusUnauthorized,
 	}
+	RequireRoleMiddleware = AppError{
+		Message:  "Failed to RequireRoleMiddleware",
+		Code:     forbidden,
+		HTTPCode: http.StatusForbidden,
+	}
+	RateLimitMiddleware = AppError{
+		Message:  "Failed to RateLimitMiddleware, too many requests",
+		Code:     tooManyRequests,
+		HTTPCode: http.StatusTooManyRequests,
+	}
+	RateLimiterErr = AppError{
+		Message: "Failed to RateLimiterErr",
+		Code:    middleware,
+	}
+	LoginLockedErr = AppError{
+		Message:  "Failed to LoginLockedErr, too many failed logins",
+		Code:     loginLocked,
+		HTTPCode: http.StatusTooManyRequests,
+	}
+	LoginLockoutErr = AppError{
+		Message: "Failed to LoginLockoutErr",
+		Code:    handlers,
+	}
+	ActingUserErr = AppError{
+		Message:  "Failed to ActingUserErr",
+		Code:     forbidden,
+		HTTPCode: http.StatusForbidden,
+	}
 	GetAllFromBackUpErr = AppError{
 		Message: "Failed to GetAllFromBackUp",
 		Code:    backUpRepo,
@@ -57,24 +218,48 @@ var (
 		Message: "Failed to InsertWordsLibraryErr",
 		Code:    repoLibrary,
 	}
-	GetTranslationEnglLikeErr = AppError{
-		Message: "Failed to GetTranslationEnglLikeErr",
+	GetTranslationsErr = AppError{
+		Message: "Failed to GetTranslationsErr",
+		Code:    repoLexemes,
+	}
+	GetTranslationsLikeErr = AppError{
+		Message: "Failed to GetTranslationsLikeErr",
+		Code:    repoLexemes,
+	}
+	SearchCandidatesErr = AppError{
+		Message: "Failed to SearchCandidatesErr",
+		Code:    repoLexemes,
+	}
+	GetTranslationsByPairErr = AppError{
+		Message: "Failed to GetTranslationsByPairErr",
+		Code:    repoLexemes,
+	}
+	CreateTranslationErr = AppError{
+		Message: "Failed to CreateTranslationErr",
+		Code:    repoLexemes,
+	}
+	GetAllWordsLibErr = AppError{
+		Message: "Failed to GetAllWords",
+		Code:    repoLibrary,
+	}
+	CountWordsLibErr = AppError{
+		Message: "Failed to CountWordsLibErr",
 		Code:    repoLibrary,
 	}
-	GetTranslationEnglErr = AppError{
-		Message: "Failed to GetTranslationEnglErr",
+	GetWordByIDLibErr = AppError{
+		Message: "Failed to GetWordByIDLibErr",
 		Code:    repoLibrary,
 	}
-	GetTranslationRusLikeErr = AppError{
-		Message: "Failed to GetTranslationRusLikeErr",
+	CreateWordLibErr = AppError{
+		Message: "Failed to CreateWordLibErr",
 		Code:    repoLibrary,
 	}
-	GetTranslationRusErr = AppError{
-		Message: "Failed to GetTranslationRusErr",
+	DeleteWordLibErr = AppError{
+		Message: "Failed to DeleteWordLibErr",
 		Code:    repoLibrary,
 	}
-	GetAllWordsLibErr = AppError{
-		Message: "Failed to GetAllWords",
+	ImportWordsLibErr = AppError{
+		Message: "Failed to ImportWordsLibErr",
 		Code:    repoLibrary,
 	}
 	UpdateWordErr = AppError{
@@ -101,6 +286,76 @@ var (
 		Message: "Failed to GetUserByEmailErr",
 		Code:    repoUsers,
 	}
+	UpdateUserRoleErr = AppError{
+		Message: "Failed to UpdateUserRoleErr",
+		Code:    repoUsers,
+	}
+	GrantAdminsErr = AppError{
+		Message: "Failed to GrantAdminsErr",
+		Code:    repoUsers,
+	}
+	CreateRefreshTokenErr = AppError{
+		Message: "Failed to CreateRefreshTokenErr",
+		Code:    repoTokens,
+	}
+	GetRefreshTokenErr = AppError{
+		Message:  "Failed to GetRefreshTokenErr",
+		Code:     unauthorized,
+		HTTPCode: http.StatusUnauthorized,
+	}
+	RotateRefreshTokenErr = AppError{
+		Message:  "Failed to RotateRefreshTokenErr",
+		Code:     unauthorized,
+		HTTPCode: http.StatusUnauthorized,
+	}
+	RevokeRefreshTokenErr = AppError{
+		Message: "Failed to RevokeRefreshTokenErr",
+		Code:    repoTokens,
+	}
+	RevokeTokenErr = AppError{
+		Message: "Failed to RevokeTokenErr",
+		Code:    repoTokens,
+	}
+	IsTokenRevokedErr = AppError{
+		Message: "Failed to IsTokenRevokedErr",
+		Code:    repoTokens,
+	}
+	DeleteExpiredTokensErr = AppError{
+		Message: "Failed to DeleteExpiredTokensErr",
+		Code:    repoTokens,
+	}
+	ReserveIdempotencyKeyErr = AppError{
+		Message: "Failed to ReserveIdempotencyKeyErr",
+		Code:    repoIdempotency,
+	}
+	SaveIdempotentResponseErr = AppError{
+		Message: "Failed to SaveIdempotentResponseErr",
+		Code:    repoIdempotency,
+	}
+	ReleaseIdempotencyKeyErr = AppError{
+		Message: "Failed to ReleaseIdempotencyKeyErr",
+		Code:    repoIdempotency,
+	}
+	DeleteExpiredIdempotencyKeysErr = AppError{
+		Message: "Failed to DeleteExpiredIdempotencyKeysErr",
+		Code:    repoIdempotency,
+	}
+	CORSMiddleware = AppError{
+		Message: "Failed to CORSMiddleware",
+		Code:    middleware,
+	}
+	IdempotencyMiddleware = AppError{
+		Message: "Failed to IdempotencyMiddleware",
+		Code:    middleware,
+	}
+	AddWordsErr = AppError{
+		Message: "Failed to AddWordsErr",
+		Code:    repoUsers,
+	}
+	UpdateUserLanguagePairErr = AppError{
+		Message: "Failed to UpdateUserLanguagePairErr",
+		Code:    repoUsers,
+	}
 	GetWordsByIDAndLimitErr = AppError{
 		Message: "Failed to GetWordsByIDAndLimitErr",
 		Code:    repoUsers,
@@ -109,14 +364,82 @@ var (
 		Message: "Failed to GetLearnByIDAndLimitErr",
 		Code:    repoUsers,
 	}
+	GetLearnedByIDAndLimitErr = AppError{
+		Message: "Failed to GetLearnedByIDAndLimitErr",
+		Code:    repoUsers,
+	}
 	DeleteLearnWordFromUserByWordErr = AppError{
 		Message: "Failed to DeleteLearnWordFromUserByWordErr",
 		Code:    repoUsers,
 	}
+	ApplyWordOperationsErr = AppError{
+		Message: "Failed to ApplyWordOperationsErr",
+		Code:    repoUsers,
+	}
 	AddWordToLearnRepoErr = AppError{
 		Message: "Failed to AddWordToLearnRepoErr",
 		Code:    repoUsers,
 	}
+	GetDueWordsErr = AppError{
+		Message: "Failed to GetDueWordsErr",
+		Code:    repoReviews,
+	}
+	GetReviewErr = AppError{
+		Message: "Failed to GetReviewErr",
+		Code:    repoReviews,
+	}
+	SaveReviewErr = AppError{
+		Message: "Failed to SaveReviewErr",
+		Code:    repoReviews,
+	}
+	CreateQuizSessionErr = AppError{
+		Message: "Failed to CreateQuizSessionErr",
+		Code:    repoQuiz,
+	}
+	GetQuizSessionErr = AppError{
+		Message: "Failed to GetQuizSessionErr",
+		Code:    repoQuiz,
+	}
+	SaveQuizSessionErr = AppError{
+		Message: "Failed to SaveQuizSessionErr",
+		Code:    repoQuiz,
+	}
+	CreateQuizAnswerErr = AppError{
+		Message: "Failed to CreateQuizAnswerErr",
+		Code:    repoQuiz,
+	}
+	CountQuizAnswersErr = AppError{
+		Message: "Failed to CountQuizAnswersErr",
+		Code:    repoQuiz,
+	}
+	GetStatsErr = AppError{
+		Message: "Failed to GetStatsErr",
+		Code:    repoQuiz,
+	}
+	GetQuizItemErr = AppError{
+		Message: "Failed to GetQuizItemErr",
+		Code:    repoQuiz,
+	}
+	AnswerQuizItemErr = AppError{
+		Message: "Failed to AnswerQuizItemErr",
+		Code:    repoQuiz,
+	}
+	GetAcceptedAnswersErr = AppError{
+		Message: "Failed to GetAcceptedAnswersErr",
+		Code:    repoQuiz,
+	}
+	GetDistractorsErr = AppError{
+		Message: "Failed to GetDistractorsErr",
+		Code:    repoQuiz,
+	}
+	GetPhrasesErr = AppError{
+		Message: "Failed to GetPhrasesErr",
+		Code:    repoLibrary,
+	}
+	GetPhraseByIDErr = AppError{
+		Message: "Failed to GetPhraseByIDErr",
+		Code:    repoLibrary,
+	}
 	DeleteLearnByUserIDAndLearnIDHandlerErr = AppError{
 		Message: "Failed to deleteLearnByUserIDAndLearnIDHandlerErr",
 		Code:    handlers,
@@ -125,6 +448,10 @@ var (
 		Message: "Failed to GetWordsByUserIDAndLimitHandlerErr",
 		Code:    handlers,
 	}
+	SearchTranslationHandlerErr = AppError{
+		Message: "Failed to SearchTranslationHandlerErr",
+		Code:    handlers,
+	}
 	GetTranslationHandlerErr = AppError{
 		Message: "Failed to GetTranslationHandlerErr",
 		Code:    handlers,
@@ -149,6 +476,14 @@ var (
 		Message: "Failed to GetLearnByUserIDAndLimitHandlerErr",
 		Code:    handlers,
 	}
+	GetLearnedByUserIDAndLimitHandlerErr = AppError{
+		Message: "Failed to GetLearnedByUserIDAndLimitHandlerErr",
+		Code:    handlers,
+	}
+	WordsBatchHandlerErr = AppError{
+		Message: "Failed to WordsBatchHandlerErr",
+		Code:    handlers,
+	}
 	MoveWordToLearnedHandlerErr = AppError{
 		Message: "Failed to MoveWordToLearnedHandlerErr",
 		Code:    handlers,
@@ -157,10 +492,94 @@ var (
 		Message: "Failed to AddWordToLearnHandlerErr",
 		Code:    handlers,
 	}
+	GetDueWordsHandlerErr = AppError{
+		Message: "Failed to GetDueWordsHandlerErr",
+		Code:    handlers,
+	}
+	AnswerReviewHandlerErr = AppError{
+		Message: "Failed to AnswerReviewHandlerErr",
+		Code:    handlers,
+	}
+	GetLibraryWordHandlerErr = AppError{
+		Message: "Failed to GetLibraryWordHandlerErr",
+		Code:    handlers,
+	}
+	CreateLibraryWordHandlerErr = AppError{
+		Message: "Failed to CreateLibraryWordHandlerErr",
+		Code:    handlers,
+	}
+	UpdateLibraryWordHandlerErr = AppError{
+		Message: "Failed to UpdateLibraryWordHandlerErr",
+		Code:    handlers,
+	}
+	DeleteLibraryWordHandlerErr = AppError{
+		Message: "Failed to DeleteLibraryWordHandlerErr",
+		Code:    handlers,
+	}
+	ImportLibraryHandlerErr = AppError{
+		Message: "Failed to ImportLibraryHandlerErr",
+		Code:    handlers,
+	}
+	RefreshTokenHandlerErr = AppError{
+		Message: "Failed to RefreshTokenHandlerErr",
+		Code:    handlers,
+	}
+	CreateTranslationHandlerErr = AppError{
+		Message: "Failed to CreateTranslationHandlerErr",
+		Code:    handlers,
+	}
+	AddLanguagePairHandlerErr = AppError{
+		Message: "Failed to AddLanguagePairHandlerErr",
+		Code:    handlers,
+	}
+	ChangeUserRoleHandlerErr = AppError{
+		Message: "Failed to ChangeUserRoleHandlerErr",
+		Code:    handlers,
+	}
+	StartQuizSessionHandlerErr = AppError{
+		Message: "Failed to StartQuizSessionHandlerErr",
+		Code:    handlers,
+	}
+	QuizAnswerHandlerErr = AppError{
+		Message: "Failed to QuizAnswerHandlerErr",
+		Code:    handlers,
+	}
+	FinishQuizSessionHandlerErr = AppError{
+		Message: "Failed to FinishQuizSessionHandlerErr",
+		Code:    handlers,
+	}
+	GetStatsHandlerErr = AppError{
+		Message: "Failed to GetStatsHandlerErr",
+		Code:    handlers,
+	}
+	NextQuizPromptHandlerErr = AppError{
+		Message: "Failed to NextQuizPromptHandlerErr",
+		Code:    handlers,
+	}
+	CheckQuizAnswerHandlerErr = AppError{
+		Message: "Failed to CheckQuizAnswerHandlerErr",
+		Code:    handlers,
+	}
+	GetPhrasesHandlerErr = AppError{
+		Message: "Failed to GetPhrasesHandlerErr",
+		Code:    handlers,
+	}
+	CheckPhraseHandlerErr = AppError{
+		Message: "Failed to CheckPhraseHandlerErr",
+		Code:    handlers,
+	}
 	DeleteLearnFromUserByIdErr = AppError{
 		Message: "Failed to DeleteLearnFromUserByIdErr",
 		Code:    services,
 	}
+	SearchTranslationsErr = AppError{
+		Message: "Failed to SearchTranslationsErr",
+		Code:    services,
+	}
+	WordsCursorErr = AppError{
+		Message: "Failed to WordsCursorErr",
+		Code:    services,
+	}
 	GetTranslationByWordErr = AppError{
 		Message: "Failed to GetTranslationByWordErr",
 		Code:    services,
@@ -173,6 +592,10 @@ var (
 		Message: "Failed to GetUserByIdErr",
 		Code:    services,
 	}
+	WordsBatchErr = AppError{
+		Message: "Failed to WordsBatchErr",
+		Code:    services,
+	}
 	MoveWordToLearnedErr = AppError{
 		Message: "Failed to MoveWordToLearnedErr",
 		Code:    services,
@@ -193,24 +616,146 @@ var (
 		Message: "Failed to GetWordsByUsIdAndLimitServiceErr",
 		Code:    services,
 	}
+	GetDueWordsServiceErr = AppError{
+		Message: "Failed to GetDueWordsServiceErr",
+		Code:    services,
+	}
+	AnswerReviewErr = AppError{
+		Message: "Failed to AnswerReviewErr",
+		Code:    services,
+	}
+	RefreshTokensErr = AppError{
+		Message:  "Failed to RefreshTokensErr",
+		Code:     unauthorized,
+		HTTPCode: http.StatusUnauthorized,
+	}
+	NewRefreshTokenErr = AppError{
+		Message: "Failed to NewRefreshTokenErr",
+		Code:    services,
+	}
+	CreateTranslationServiceErr = AppError{
+		Message: "Failed to CreateTranslationServiceErr",
+		Code:    services,
+	}
+	LanguagePairErr = AppError{
+		Message: "Failed to LanguagePairErr, unsupported language pair",
+		Code:    services,
+	}
+	AddLanguagePairErr = AppError{
+		Message: "Failed to AddLanguagePairErr",
+		Code:    services,
+	}
+	ChangeUserRoleErr = AppError{
+		Message: "Failed to ChangeUserRoleErr",
+		Code:    services,
+	}
+	StartQuizSessionErr = AppError{
+		Message: "Failed to StartQuizSessionErr",
+		Code:    services,
+	}
+	QuizAnswerErr = AppError{
+		Message: "Failed to QuizAnswerErr",
+		Code:    services,
+	}
+	FinishQuizSessionErr = AppError{
+		Message: "Failed to FinishQuizSessionErr",
+		Code:    services,
+	}
+	GetStatsServiceErr = AppError{
+		Message: "Failed to GetStatsServiceErr",
+		Code:    services,
+	}
+	NextQuizPromptErr = AppError{
+		Message: "Failed to NextQuizPromptErr",
+		Code:    services,
+	}
+	CheckQuizAnswerErr = AppError{
+		Message: "Failed to CheckQuizAnswerErr",
+		Code:    services,
+	}
+	GetPhrasesServiceErr = AppError{
+		Message: "Failed to GetPhrasesServiceErr",
+		Code:    services,
+	}
+	SaveLibraryWordErr = AppError{
+		Message: "Failed to SaveLibraryWordErr",
+		Code:    services,
+	}
 )
 
 func (appError *AppError) Error() string {
 	return appError.Code + ": " + appError.Message
 }
 
+func (appError *AppError) Unwrap() error {
+	return appError.Err
+}
+
+// Is matches every error appended to the same package error, errors.Is(err, &apperrors.UserNotFoundErr).
+func (appError *AppError) Is(target error) bool {
+	targetErr, ok := target.(*AppError)
+	if !ok {
+		return false
+	}
+
+	return appError.root() == targetErr.root()
+}
+
+func (appError *AppError) root() *AppError {
+	if appError.origin != nil {
+		return appError.origin
+	}
+
+	return appError
+}
+
+// AppendMessage keeps the code and the status, the first error of anyErrs becomes the cause.
 func (appError *AppError) AppendMessage(anyErrs ...interface{}) *AppError {
+	cause := appError.Err
+	for _, anyErr := range anyErrs {
+		if err, ok := anyErr.(error); ok {
+			cause = err
+			break
+		}
+	}
+
 	return &AppError{
-		Message: fmt.Sprintf("%v : %v", appError.Message, anyErrs),
-		Code:    appError.Code,
+		Message:  fmt.Sprintf("%v : %v", appError.Message, anyErrs),
+		Code:     appError.Code,
+		HTTPCode: appError.HTTPCode,
+		Err:      cause,
+		origin:   appError.root(),
 	}
 }
 
 func IsAppError(err1 error, err2 *AppError) bool {
-	err, ok := err1.(*AppError)
-	if !ok {
-		return false
+	return errors.Is(err1, err2)
+}
+
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            badRequest,
+	http.StatusUnauthorized:          unauthorized,
+	http.StatusForbidden:             forbidden,
+	http.StatusNotFound:              notFound,
+	http.StatusConflict:              conflict,
+	http.StatusRequestEntityTooLarge: requestTooLarge,
+	http.StatusTooManyRequests:       tooManyRequests,
+}
+
+// Status returns the status and the code of the outermost error of the chain which has a status,
+// otherwise fallback and its generic code.
+func Status(err error, fallback int) (int, string) {
+	for err != nil {
+		if appErr, ok := err.(*AppError); ok && appErr.HTTPCode != 0 {
+			return appErr.HTTPCode, appErr.Code
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	if code, ok := statusCodes[fallback]; ok {
+		return fallback, code
 	}
 
-	return err.Code == err2.Code
+	return fallback, internal
 }
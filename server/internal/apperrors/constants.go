@@ -1,14 +1,47 @@
 package apperrors
 
 const (
-	envInit     = "ENV_INIT_ERR"
-	database    = "DATABASE_INIT_ERR"
-	envParse    = "ENV_PARSE_ERR"
-	log         = "LOG_NEW_LOG_ERR"
-	middleware  = "MIDDLEWARE_ERR"
-	backUpRepo  = "BACKUP_REPO_ERR"
-	repoLibrary = "REPO_LIBRARY_ERR"
-	repoUsers   = "REPO_USERS_ERR"
-	handlers    = "HANDLERS_ERR"
-	services    = "SERVICES_ERR"
+	envInit         = "ENV_INIT_ERR"
+	database        = "DATABASE_INIT_ERR"
+	migrations      = "MIGRATIONS_ERR"
+	envParse        = "ENV_PARSE_ERR"
+	log             = "LOG_NEW_LOG_ERR"
+	middleware      = "MIDDLEWARE_ERR"
+	backUpRepo      = "BACKUP_REPO_ERR"
+	repoLibrary     = "REPO_LIBRARY_ERR"
+	repoUsers       = "REPO_USERS_ERR"
+	repoReviews     = "REPO_REVIEWS_ERR"
+	repoTokens      = "REPO_TOKENS_ERR"
+	repoLexemes     = "REPO_LEXEMES_ERR"
+	repoQuiz        = "REPO_QUIZ_ERR"
+	repoIdempotency = "REPO_IDEMPOTENCY_ERR"
+	handlers        = "HANDLERS_ERR"
+	services        = "SERVICES_ERR"
+)
+
+// The codes of the errors with a status, the clients match on them.
+const (
+	badRequest               = "BAD_REQUEST"
+	validationFailed         = "VALIDATION_FAILED"
+	unauthorized             = "UNAUTHORIZED"
+	invalidCredentials       = "INVALID_CREDENTIALS"
+	forbidden                = "FORBIDDEN"
+	notFound                 = "NOT_FOUND"
+	userNotFound             = "USER_NOT_FOUND"
+	wordNotFound             = "WORD_NOT_FOUND"
+	conflict                 = "CONFLICT"
+	emailTaken               = "EMAIL_TAKEN"
+	quizSessionNotFound      = "QUIZ_SESSION_NOT_FOUND"
+	quizSessionFinished      = "QUIZ_SESSION_FINISHED"
+	quizSessionGraded        = "QUIZ_SESSION_GRADED"
+	quizPromptMismatch       = "QUIZ_PROMPT_MISMATCH"
+	quizNoWords              = "QUIZ_NO_WORDS"
+	phraseNotFound           = "PHRASE_NOT_FOUND"
+	idempotencyKeyInvalid    = "IDEMPOTENCY_KEY_INVALID"
+	idempotencyKeyReused     = "IDEMPOTENCY_KEY_REUSED"
+	idempotencyKeyInProgress = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	requestTooLarge          = "REQUEST_TOO_LARGE"
+	tooManyRequests          = "TOO_MANY_REQUESTS"
+	loginLocked              = "LOGIN_LOCKED"
+	internal                 = "INTERNAL"
 )
@@ -29,11 +29,58 @@ type PostgresConfig struct {
 	TimeoutQuery string `env:"TIMEOUT_QUERY"`
 }
 
+// ServerConfig is the HTTP server. AdminEmails are made admins on start, the first admin of a database
+// doesn't need an edit by hand. MetricsAddr is the internal listener of /metrics, it stays off the public port.
 type ServerConfig struct {
-	AppPort                string `env:"APP_PORT"`
-	SecretKey              string `env:"SECRET_KEY"`
-	ExpirationJWTInSeconds string `env:"EXPIRATION_JWT_SECONDS"`
-	TimeoutContext         string `env:"TIMEOUT_CONTEXT"`
+	AppPort                    string   `env:"APP_PORT"`
+	SecretKey                  string   `env:"SECRET_KEY"`
+	ExpirationJWTInSeconds     string   `env:"EXPIRATION_JWT_SECONDS"`
+	ExpirationRefreshInSeconds string   `env:"EXPIRATION_REFRESH_SECONDS"`
+	TimeoutContext             string   `env:"TIMEOUT_CONTEXT"`
+	RevocationStore            string   `env:"REVOCATION_STORE" envDefault:"postgres"`
+	MigrateOnStart             bool     `env:"MIGRATE_ON_START" envDefault:"true"`
+	ReadHeaderTimeoutInSeconds string   `env:"READ_HEADER_TIMEOUT_SECONDS" envDefault:"5"`
+	ReadTimeoutInSeconds       string   `env:"READ_TIMEOUT_SECONDS" envDefault:"15"`
+	WriteTimeoutInSeconds      string   `env:"WRITE_TIMEOUT_SECONDS" envDefault:"60"`
+	IdleTimeoutInSeconds       string   `env:"IDLE_TIMEOUT_SECONDS" envDefault:"120"`
+	ShutdownTimeoutInSeconds   string   `env:"SHUTDOWN_TIMEOUT_SECONDS" envDefault:"30"`
+	AdminEmails                []string `env:"ADMIN_EMAILS" envSeparator:","`
+	MetricsAddr                string   `env:"METRICS_ADDR" envDefault:"127.0.0.1:9091"`
+	CORS                       *CORSConfig
+	RateLimit                  *RateLimitConfig
+}
+
+// CORSConfig lets a web front end on another origin call the API, no allowed origins keeps the API same-origin.
+// An origin "*" allows every origin. The security headers go on every response, HSTS only when HSTSMaxAgeInSeconds
+// is set: the server speaks plain HTTP, it is for a deploy behind a TLS proxy and pins every subdomain to HTTPS.
+type CORSConfig struct {
+	AllowedOrigins        []string `env:"CORS_ALLOWED_ORIGINS" envSeparator:","`
+	AllowedMethods        []string `env:"CORS_ALLOWED_METHODS" envSeparator:"," envDefault:"GET,POST,PUT,DELETE"`
+	AllowedHeaders        []string `env:"CORS_ALLOWED_HEADERS" envSeparator:"," envDefault:"Authorization,Content-Type,Idempotency-Key"`
+	ExposedHeaders        []string `env:"CORS_EXPOSED_HEADERS" envSeparator:"," envDefault:"Idempotent-Replayed"`
+	MaxAgeInSeconds       string   `env:"CORS_MAX_AGE_SECONDS" envDefault:"600"`
+	HSTSMaxAgeInSeconds   string   `env:"HSTS_MAX_AGE_SECONDS"`
+	ContentSecurityPolicy string   `env:"CONTENT_SECURITY_POLICY" envDefault:"default-src 'none'; frame-ancestors 'none'"`
+}
+
+// RateLimitConfig sets the token buckets, a rate is the requests a minute and a burst how many of them may come at once.
+// A rate of 0 turns the limit off. TrustedProxyHops is how many proxies in front of the server append to X-Forwarded-For,
+// the client IP is the entry the outermost of them has appended. 0 takes the address of the connection.
+type RateLimitConfig struct {
+	TrustedProxyHops         int `env:"RATE_LIMIT_TRUSTED_PROXY_HOPS" envDefault:"0"`
+	LoginPerMinuteByIP       int `env:"LOGIN_RATE_PER_MINUTE_BY_IP" envDefault:"20"`
+	LoginBurstByIP           int `env:"LOGIN_BURST_BY_IP" envDefault:"10"`
+	LoginPerMinuteByEmail    int `env:"LOGIN_RATE_PER_MINUTE_BY_EMAIL" envDefault:"5"`
+	LoginBurstByEmail        int `env:"LOGIN_BURST_BY_EMAIL" envDefault:"5"`
+	SignUpPerMinuteByIP      int `env:"SIGN_UP_RATE_PER_MINUTE_BY_IP" envDefault:"5"`
+	SignUpBurstByIP          int `env:"SIGN_UP_BURST_BY_IP" envDefault:"5"`
+	TranslatePerMinuteByIP   int `env:"TRANSLATE_RATE_PER_MINUTE_BY_IP" envDefault:"60"`
+	TranslateBurstByIP       int `env:"TRANSLATE_BURST_BY_IP" envDefault:"20"`
+	CheckPerMinuteByUser     int `env:"CHECK_RATE_PER_MINUTE_BY_USER" envDefault:"60"`
+	CheckBurstByUser         int `env:"CHECK_BURST_BY_USER" envDefault:"20"`
+	LoginLockoutFailures     int `env:"LOGIN_LOCKOUT_FAILURES" envDefault:"5"`
+	LoginLockoutInSeconds    int `env:"LOGIN_LOCKOUT_SECONDS" envDefault:"30"`
+	LoginMaxLockoutInSeconds int `env:"LOGIN_MAX_LOCKOUT_SECONDS" envDefault:"3600"`
 }
 
 func NewConfig(logger *logrus.Logger) (*Config, error) {
@@ -55,6 +102,18 @@ func NewConfig(logger *logrus.Logger) (*Config, error) {
 		return nil, appErr
 	}
 
+	confServer.CORS = &CORSConfig{}
+	if err := env.Parse(confServer.CORS); err != nil {
+		appErr := apperrors.EnvConfigParseError.AppendMessage(err)
+		return nil, appErr
+	}
+
+	confServer.RateLimit = &RateLimitConfig{}
+	if err := env.Parse(confServer.RateLimit); err != nil {
+		appErr := apperrors.EnvConfigParseError.AppendMessage(err)
+		return nil, appErr
+	}
+
 	conf := Config{AppPort: confServer.AppPort, Postgres: confPsql, Server: confServer}
 
 	logger.Info("Config has been parsed")
@@ -16,6 +16,8 @@ import (
 
 type PostgresDB interface {
 	SetupDatabase(ctx context.Context, conf *config.Config, logger *logrus.Logger) (*gorm.DB, error)
+	Ping(ctx context.Context) error
+	Close() error
 }
 
 type postgresDB struct {
@@ -39,7 +41,8 @@ func (p *postgresDB) SetupDatabase(ctx context.Context, conf *config.Config, log
 	)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
 	})
 	if err != nil {
 		appErr := apperrors.SetupDatabaseErr.AppendMessage(err)
@@ -77,5 +80,41 @@ func (p *postgresDB) SetupDatabase(ctx context.Context, conf *config.Config, log
 	}
 
 	log.Info("DB Postgres has been connected, DB.Ping success ")
+	p.DB = db
 	return db, nil
 }
+
+func (p *postgresDB) Ping(ctx context.Context) error {
+	if p.DB == nil {
+		return apperrors.PingDatabaseErr.AppendMessage("database is not set up")
+	}
+
+	sqlDB, err := p.DB.DB()
+	if err != nil {
+		return apperrors.PingDatabaseErr.AppendMessage(err)
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return apperrors.PingDatabaseErr.AppendMessage(err)
+	}
+
+	return nil
+}
+
+// Close closes the connection pool, it waits for the queries in progress.
+func (p *postgresDB) Close() error {
+	if p.DB == nil {
+		return nil
+	}
+
+	sqlDB, err := p.DB.DB()
+	if err != nil {
+		return apperrors.CloseDatabaseErr.AppendMessage(err)
+	}
+
+	if err := sqlDB.Close(); err != nil {
+		return apperrors.CloseDatabaseErr.AppendMessage(err)
+	}
+
+	return nil
+}
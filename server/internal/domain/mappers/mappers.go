@@ -5,6 +5,7 @@ import (
 	"server/internal/domain/models"
 	"server/internal/domain/requests"
 	"server/internal/domain/responses"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -15,8 +16,8 @@ func MapReqCreateUsToUser(userReq *requests.CreateUserRequest) *models.User {
 		ID:       &userID,
 		Name:     userReq.Name,
 		LastName: userReq.LastName,
-		Email:    userReq.Email,
-		Role:     userReq.Role,
+		Email:    strings.ToLower(strings.TrimSpace(userReq.Email)),
+		Role:     models.RoleUser,
 	}
 
 }
@@ -33,16 +34,55 @@ func ScanUser(u *models.User) {
 	u.ID = &bid
 }
 
-func MapLibraryToWords(library []*models.Library) []*models.Word {
+func MapTranslationsToGetTranslResponse(translations []*models.Translation) []*responses.GetTranslResponse {
+	words := []*responses.GetTranslResponse{}
+	for _, translation := range translations {
+		if translation.Source == nil || translation.Target == nil {
+			continue
+		}
+
+		words = append(words, MapTranslationToGetTranslResponse(translation))
+	}
+
+	return words
+}
+
+func MapTranslationToGetTranslResponse(translation *models.Translation) *responses.GetTranslResponse {
+	tempWord := &responses.GetTranslResponse{
+		From:        translation.Source.Language,
+		To:          translation.Target.Language,
+		Word:        translation.Source.Text,
+		Translation: translation.Target.Text,
+	}
+
+	for _, lexeme := range []*models.Lexeme{translation.Source, translation.Target} {
+		switch lexeme.Language {
+		case models.LanguageEnglish:
+			tempWord.English = lexeme.Text
+		case models.LanguageRussian:
+			tempWord.Russian = lexeme.Text
+		}
+	}
+
+	return tempWord
+}
+
+func MapTranslationsToWords(translations []*models.Translation, from string, to string) []*models.Word {
 	words := []*models.Word{}
-	for _, libWord := range library {
+	for _, translation := range translations {
+		if translation.Source == nil || translation.Target == nil {
+			continue
+		}
+
 		byd := uuid.New()
 		tempWord := &models.Word{
 			ID:            &byd,
-			Russian:       libWord.Russian,
-			English:       libWord.English,
-			Theme:         libWord.Theme,
-			PartsOfSpeech: libWord.PartsOfSpeech,
+			Russian:       translation.Source.Text,
+			English:       translation.Target.Text,
+			Theme:         translation.Source.Theme,
+			PartsOfSpeech: translation.Source.PartsOfSpeech,
+			LanguageFrom:  from,
+			LanguageTo:    to,
 		}
 
 		words = append(words, tempWord)
@@ -51,22 +91,26 @@ func MapLibraryToWords(library []*models.Library) []*models.Word {
 	return words
 }
 
-func MapLibraryToWordsGetTranslResponse(library []*models.Library) []*responses.GetTranslResponse {
-	words := []*responses.GetTranslResponse{}
-	for _, libWord := range library {
-		tempWord := &responses.GetTranslResponse{
-			Russian: libWord.Russian,
-			English: libWord.English,
-		}
+func MapCreateTranslationReqToLexemes(translReq *requests.CreateTranslationRequest) (*models.Lexeme, *models.Lexeme) {
+	source := &models.Lexeme{
+		Language:      translReq.From,
+		Text:          translReq.Word,
+		Theme:         translReq.Theme,
+		PartsOfSpeech: translReq.PartsOfSpeech,
+	}
 
-		words = append(words, tempWord)
+	target := &models.Lexeme{
+		Language:      translReq.To,
+		Text:          translReq.Translation,
+		Theme:         translReq.Theme,
+		PartsOfSpeech: translReq.PartsOfSpeech,
 	}
 
-	return words
+	return source, target
 }
 
-func MapTokenToLoginResponse(token string, expiresAt string) *responses.LoginResponse {
-	return &responses.LoginResponse{Token: token, ExpiresIn: expiresAt, TokenType: "jwt", RefreshToken: "it'll be soon"}
+func MapTokenToLoginResponse(token string, expiresAt string, refreshToken string, refreshExpiresAt string) *responses.LoginResponse {
+	return &responses.LoginResponse{Token: token, ExpiresIn: expiresAt, TokenType: "jwt", RefreshToken: refreshToken, RefreshExpiresIn: refreshExpiresAt}
 }
 
 func MapWordsToWordsResp(words []*models.Word) []*responses.WordResp {
@@ -77,6 +121,8 @@ func MapWordsToWordsResp(words []*models.Word) []*responses.WordResp {
 			Russian:       word.Russian,
 			ID:            word.ID.String(),
 			PartsOfSpeech: word.PartsOfSpeech,
+			LanguageFrom:  word.LanguageFrom,
+			LanguageTo:    word.LanguageTo,
 		}
 
 		wordsResp = append(wordsResp, wordResp)
@@ -84,3 +130,110 @@ func MapWordsToWordsResp(words []*models.Word) []*responses.WordResp {
 
 	return wordsResp
 }
+
+func MapReviewToReviewResp(review *models.Review) *responses.ReviewResp {
+	return &responses.ReviewResp{
+		WordID:       review.WordID.String(),
+		EaseFactor:   review.EaseFactor,
+		Interval:     review.Interval,
+		Repetitions:  review.Repetitions,
+		NextReviewAt: review.NextReviewAt,
+	}
+}
+
+func MapLibraryWordReqToLibrary(wordReq *requests.LibraryWordRequest) *models.Library {
+	phrases := []*models.Phrase{}
+	for _, phraseReq := range wordReq.Phrases {
+		if phraseReq == nil {
+			continue
+		}
+
+		phrase := &models.Phrase{
+			ID:      phraseReq.ID,
+			English: phraseReq.English,
+			Russian: phraseReq.Russian,
+		}
+
+		phrases = append(phrases, phrase)
+	}
+
+	return &models.Library{
+		English:       wordReq.English,
+		Russian:       wordReq.Russian,
+		Theme:         wordReq.Theme,
+		PartsOfSpeech: wordReq.PartsOfSpeech,
+		Phrases:       phrases,
+		Exceptions:    wordReq.Exceptions,
+	}
+}
+
+func MapLibraryToLibraryWordResp(libWord *models.Library) *responses.LibraryWordResp {
+	phrases := []*responses.PhraseResp{}
+	for _, phrase := range libWord.Phrases {
+		phraseResp := &responses.PhraseResp{
+			ID:      phrase.ID,
+			English: phrase.English,
+			Russian: phrase.Russian,
+		}
+
+		phrases = append(phrases, phraseResp)
+	}
+
+	return &responses.LibraryWordResp{
+		ID:            libWord.ID,
+		English:       libWord.English,
+		Russian:       libWord.Russian,
+		Theme:         libWord.Theme,
+		PartsOfSpeech: libWord.PartsOfSpeech,
+		Phrases:       phrases,
+		Exceptions:    libWord.Exceptions,
+	}
+}
+
+func MapQuizSessionToQuizSessionResp(session *models.QuizSession) *responses.QuizSessionResp {
+	return &responses.QuizSessionResp{
+		ID:         session.ID.String(),
+		Mode:       session.Mode,
+		Direction:  session.Direction,
+		Size:       len(session.Items),
+		Choices:    session.Choices,
+		StartedAt:  session.StartedAt,
+		FinishedAt: session.FinishedAt,
+	}
+}
+
+func MapQuizItemToQuizPromptResp(item *models.QuizItem) *responses.QuizPromptResp {
+	promptResp := &responses.QuizPromptResp{
+		SessionID:      item.SessionID.String(),
+		Position:       item.Position,
+		WordID:         item.WordID.String(),
+		Prompt:         item.Word.Russian,
+		Language:       item.Word.LanguageFrom,
+		AnswerLanguage: item.Word.LanguageTo,
+		Theme:          item.Word.Theme,
+		PartsOfSpeech:  item.Word.PartsOfSpeech,
+	}
+	if item.Reverse {
+		promptResp.Prompt = item.Word.English
+		promptResp.Language, promptResp.AnswerLanguage = item.Word.LanguageTo, item.Word.LanguageFrom
+	}
+
+	return promptResp
+}
+
+func MapWordOperationsToWordsBatchResp(ops []*models.WordOperation) *responses.WordsBatchResp {
+	batchResp := &responses.WordsBatchResp{Results: make([]*responses.WordOperationResp, 0, len(ops))}
+	for _, op := range ops {
+		if op.Status == models.WordOperationApplied {
+			batchResp.Applied++
+		}
+
+		batchResp.Results = append(batchResp.Results, &responses.WordOperationResp{
+			WordID: op.WordID.String(),
+			Action: op.Action,
+			Status: op.Status,
+		})
+	}
+
+	return batchResp
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdempotencyKey keeps the response of a mutation, a retry with the same key gets it again instead of a second run.
+// Status is 0 while the first request is still running, ExpiresAt is a short lease then.
+type IdempotencyKey struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	UserID      string    `json:"user_id" gorm:"uniqueIndex:idx_idempotency_keys_user_key"`
+	Key         string    `json:"key" gorm:"uniqueIndex:idx_idempotency_keys_user_key"`
+	Fingerprint string    `json:"fingerprint"`
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at" gorm:"index"`
+}
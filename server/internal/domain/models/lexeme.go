@@ -0,0 +1,46 @@
+package models
+
+import "gorm.io/gorm"
+
+// ISO 639-1 codes of the supported dictionary languages.
+const (
+	LanguageEnglish   = "en"
+	LanguageRussian   = "ru"
+	LanguageUkrainian = "uk"
+	LanguageGerman    = "de"
+	LanguageSpanish   = "es"
+)
+
+var Languages = []string{LanguageEnglish, LanguageRussian, LanguageUkrainian, LanguageGerman, LanguageSpanish}
+
+func IsLanguage(code string) bool {
+	for _, language := range Languages {
+		if language == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Lexeme is a word or an expression in one language.
+type Lexeme struct {
+	gorm.Model
+	ID            int    `json:"id" gorm:"primaryKey"`
+	Language      string `json:"language" gorm:"uniqueIndex:idx_lexemes_language_text"`
+	Text          string `json:"text" gorm:"uniqueIndex:idx_lexemes_language_text"`
+	Theme         string `json:"theme"`
+	PartsOfSpeech string `json:"part_of_speech"`
+}
+
+// Translation is a directed link between lexemes, both directions are stored.
+// LibraryID is set when the link mirrors a Library entry.
+type Translation struct {
+	gorm.Model
+	ID        int     `json:"id" gorm:"primaryKey"`
+	SourceID  int     `json:"source_id" gorm:"uniqueIndex:idx_translations_source_target"`
+	Source    *Lexeme `json:"source"`
+	TargetID  int     `json:"target_id" gorm:"uniqueIndex:idx_translations_source_target"`
+	Target    *Lexeme `json:"target"`
+	LibraryID *int    `json:"library_id" gorm:"index"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	QuizModeTest  = "test"
+	QuizModeLearn = "learn"
+)
+
+// A forward prompt shows the LanguageFrom text of a word and expects the LanguageTo text, a reverse one the other way round.
+const (
+	QuizDirectionForward = "forward"
+	QuizDirectionReverse = "reverse"
+	QuizDirectionMix     = "mix"
+)
+
+// QuizSession is one run of the test or the learn mode, FinishedAt stays nil until the client finishes it.
+// A session started with a size has Items, the server asks and grades them, otherwise the client reports its answers.
+// With Choices every prompt comes with that many options, the distractors share the theme and the part of speech of the word.
+type QuizSession struct {
+	gorm.Model
+	ID         *uuid.UUID  `json:"id" gorm:"primaryKey"`
+	UserID     *uuid.UUID  `json:"user_id" gorm:"index"`
+	Mode       string      `json:"mode"`
+	Direction  string      `json:"direction"`
+	Choices    int         `json:"choices"`
+	StartedAt  time.Time   `json:"started_at"`
+	FinishedAt *time.Time  `json:"finished_at"`
+	Items      []*QuizItem `json:"-" gorm:"foreignKey:SessionID"`
+}
+
+// QuizItem is a prompt of a session, the items are asked by Position.
+// The learn mode asks a word again at the end of the queue until it is answered right.
+type QuizItem struct {
+	gorm.Model
+	SessionID  *uuid.UUID `json:"session_id" gorm:"index:idx_quiz_items_session_position"`
+	Position   int        `json:"position" gorm:"index:idx_quiz_items_session_position"`
+	WordID     *uuid.UUID `json:"word_id"`
+	Word       *Word      `json:"-"`
+	Reverse    bool       `json:"reverse"`
+	PromptedAt *time.Time `json:"prompted_at"`
+	AnsweredAt *time.Time `json:"answered_at"`
+}
+
+// QuizAnswer is one answer of a session, Typo marks an answer accepted with a spelling mistake.
+type QuizAnswer struct {
+	gorm.Model
+	SessionID  *uuid.UUID `json:"session_id" gorm:"index"`
+	UserID     *uuid.UUID `json:"user_id" gorm:"index:idx_quiz_answers_user_answered"`
+	WordID     *uuid.UUID `json:"word_id" gorm:"index"`
+	Correct    bool       `json:"correct"`
+	Typo       bool       `json:"typo"`
+	LatencyMs  int64      `json:"latency_ms"`
+	AnsweredAt time.Time  `json:"answered_at" gorm:"index:idx_quiz_answers_user_answered"`
+}
@@ -0,0 +1,7 @@
+package models
+
+// RateLimit is a token bucket, it refills PerMinute tokens a minute and holds Burst of them at most.
+type RateLimit struct {
+	PerMinute int
+	Burst     int
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshToken is stored as a sha256 hash, the plain token is only known by the client.
+type RefreshToken struct {
+	gorm.Model
+	UserID    *uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex"`
+	Device    string     `json:"device"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
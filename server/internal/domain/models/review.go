@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Review keeps the SM-2 spaced repetition state of one word for one user.
+type Review struct {
+	gorm.Model
+	UserID       *uuid.UUID `json:"user_id" gorm:"uniqueIndex:idx_reviews_user_word"`
+	WordID       *uuid.UUID `json:"word_id" gorm:"uniqueIndex:idx_reviews_user_word"`
+	EaseFactor   float64    `json:"ease_factor"`
+	Interval     int        `json:"interval"`
+	Repetitions  int        `json:"repetitions"`
+	NextReviewAt time.Time  `json:"next_review_at" gorm:"index"`
+}
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// RevokedToken marks an access token as logged out until the token expires by itself.
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"primaryKey"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+}
@@ -5,19 +5,31 @@ import (
 	"gorm.io/gorm"
 )
 
+// The roles rank user < teacher < admin, a teacher reads, adds and edits the library words and an admin manages
+// the library and the roles as well.
+const (
+	RoleUser    = "user"
+	RoleTeacher = "teacher"
+	RoleAdmin   = "admin"
+)
+
+// User keeps the language pair chosen last in LanguageFrom and LanguageTo, empty ones are the ru -> en pair.
 type User struct {
 	gorm.Model
-	ID       *uuid.UUID `json:"id" gorm:"primaryKey"`
-	Email    string     `json:"user_email"`
-	Name     string     `json:"first_name"`
-	LastName string     `json:"last_name"`
-	Password string     `json:"password"`
-	Role     string     `json:"role"`
-	Words    []*Word    `gorm:"many2many:user_words;" json:"user_words"`
-	Learn    []*Word    `gorm:"many2many:user_learn;" json:"user_learn"`
-	Learned  []*Word    `gorm:"many2many:user_learned;" json:"user_learned"`
+	ID           *uuid.UUID `json:"id" gorm:"primaryKey"`
+	Email        string     `json:"user_email" gorm:"uniqueIndex:idx_users_email"`
+	Name         string     `json:"first_name"`
+	LastName     string     `json:"last_name"`
+	Password     string     `json:"password"`
+	Role         string     `json:"role"`
+	LanguageFrom string     `json:"language_from"`
+	LanguageTo   string     `json:"language_to"`
 }
 
+// Word is a dictionary entry of the LanguageFrom -> LanguageTo list, it is shared by all users.
+// The ru -> en words reference their Library entry, the other pairs come from the translations.
+// English and Russian keep their names for the ru -> en list, in other pairs
+// Russian holds the LanguageFrom text and English the LanguageTo text.
 type Word struct {
 	gorm.Model
 	ID            *uuid.UUID `json:"id" gorm:"primaryKey"`
@@ -25,4 +37,36 @@ type Word struct {
 	Russian       string     `json:"russian"`
 	Theme         string     `json:"theme"`
 	PartsOfSpeech string     `json:"part_of_speech"`
+	LanguageFrom  string     `json:"language_from" gorm:"default:ru;index"`
+	LanguageTo    string     `json:"language_to" gorm:"default:en;index"`
+	LibraryID     *int       `json:"library_id" gorm:"uniqueIndex"`
+	Library       *Library   `json:"-"`
+}
+
+// Progress is the state of a word for a user, the row is created on the first interaction with the word.
+// A word without progress is in the user's word list until it is learned.
+type Progress struct {
+	gorm.Model
+	UserID  *uuid.UUID `json:"user_id" gorm:"uniqueIndex:idx_progresses_user_word"`
+	WordID  *uuid.UUID `json:"word_id" gorm:"uniqueIndex:idx_progresses_user_word"`
+	Word    *Word      `json:"-"`
+	InLearn bool       `json:"in_learn" gorm:"index"`
+	Learned bool       `json:"learned" gorm:"index"`
+}
+
+// The actions of a word list batch and what became of every item.
+const (
+	WordActionMoveToLearned = "move_to_learned"
+	WordActionAddToLearn    = "add_to_learn"
+	WordActionDeleteLearn   = "delete_learn"
+
+	WordOperationApplied  = "applied"
+	WordOperationNotFound = "not_found"
+)
+
+// WordOperation is one item of a word list batch, the repository fills Status.
+type WordOperation struct {
+	WordID *uuid.UUID
+	Action string
+	Status string
 }
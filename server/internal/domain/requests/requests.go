@@ -1,33 +1,165 @@
 package requests
 
+import "time"
+
 type CreateUserRequest struct {
-	Email    string `json:"email"`
-	Name     string `json:"name"`
-	LastName string `json:"last_name"`
-	Password string `json:"password"`
-	Role     string `json:"role"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Name     string `json:"name" validate:"required,max=100"`
+	LastName string `json:"last_name" validate:"max=100"`
+	Password string `json:"password" validate:"required,password"`
 }
 
+// GetWordsByUsIdAndLimitRequest takes the limit of the deprecated GET body as a string, the way the old clients sent it.
 type GetWordsByUsIdAndLimitRequest struct {
-	Limit string `json:"limit"`
-	ID    string `json:"user_id"`
+	Limit         int    `json:"limit,string" validate:"omitempty,min=1,max=100"`
+	ID            string `json:"user_id" validate:"omitempty,uuid"`
+	From          string `json:"from" validate:"omitempty,language"`
+	To            string `json:"to" validate:"omitempty,language"`
+	Theme         string `json:"theme" validate:"max=100"`
+	PartsOfSpeech string `json:"part_of_speech" validate:"max=100"`
+	Search        string `json:"q" validate:"max=100"`
+	Cursor        string `json:"cursor" validate:"max=200"`
 }
 
 type DeleteWordFromUserByIDRequest struct {
-	UserID string `json:"user_id"`
-	WordID string `json:"word_id"`
+	UserID string `json:"user_id" validate:"omitempty,uuid"`
+	WordID string `json:"word_id" validate:"required,uuid"`
 }
 
 type AddWordToLearnedRequest struct {
-	UserID string `json:"user_id"`
-	WordID string `json:"word_id"`
+	UserID string `json:"user_id" validate:"omitempty,uuid"`
+	WordID string `json:"word_id" validate:"required,uuid"`
+}
+
+// WordsBatchRequest applies up to 100 word list changes in one transaction.
+type WordsBatchRequest struct {
+	UserID     string                  `json:"user_id" validate:"omitempty,uuid"`
+	Operations []*WordOperationRequest `json:"operations" validate:"required,min=1,max=100,dive,required"`
+}
+
+type WordOperationRequest struct {
+	WordID string `json:"word_id" validate:"required,uuid"`
+	Action string `json:"action" validate:"required,oneof=move_to_learned add_to_learn delete_learn"`
 }
 
 type TranslationRequest struct {
-	Word string `json:"word"`
+	Word string `json:"word" validate:"required,max=100"`
+	From string `json:"from" validate:"omitempty,language"`
+	To   string `json:"to" validate:"omitempty,language"`
+}
+
+type SearchTranslationRequest struct {
+	Word  string `json:"word" validate:"required,max=100"`
+	From  string `json:"from" validate:"omitempty,language"`
+	To    string `json:"to" validate:"omitempty,language"`
+	Limit int    `json:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type CreateTranslationRequest struct {
+	From          string `json:"from" validate:"required,language"`
+	Word          string `json:"word" validate:"required,max=100"`
+	To            string `json:"to" validate:"required,language,nefield=From"`
+	Translation   string `json:"translation" validate:"required,max=200"`
+	Theme         string `json:"theme" validate:"max=100"`
+	PartsOfSpeech string `json:"part_of_speech" validate:"max=100"`
+}
+
+type AddLanguagePairRequest struct {
+	UserID string `json:"user_id" validate:"omitempty,uuid"`
+	From   string `json:"from" validate:"required,language"`
+	To     string `json:"to" validate:"required,language,nefield=From"`
+}
+
+type ChangeUserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user teacher admin"`
 }
 
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,max=72"`
+	Device   string `json:"device" validate:"max=100"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"max=256"`
+}
+
+type ReviewAnswerRequest struct {
+	UserID  string `json:"user_id" validate:"omitempty,uuid"`
+	WordID  string `json:"word_id" validate:"required,uuid"`
+	Quality int    `json:"quality" validate:"min=0,max=5"`
+}
+
+type LibraryWordRequest struct {
+	English       string           `json:"english" validate:"required,max=100"`
+	Russian       string           `json:"russian" validate:"required,max=200"`
+	Theme         string           `json:"theme" validate:"max=100"`
+	PartsOfSpeech string           `json:"part_of_speech" validate:"max=100"`
+	Phrases       []*PhraseRequest `json:"library_phrases" validate:"dive"`
+	Exceptions    string           `json:"exceptions" validate:"max=200"`
+}
+
+type PhraseRequest struct {
+	ID      int    `json:"id"`
+	English string `json:"english" validate:"required,max=300"`
+	Russian string `json:"russian" validate:"required,max=300"`
+}
+
+// StartQuizSessionRequest opens a session, StartedAt lets a client report a session it ran offline.
+// With a Size the server picks the words of the From -> To list and grades the answers itself.
+type StartQuizSessionRequest struct {
+	UserID    string    `json:"user_id" validate:"omitempty,uuid"`
+	Mode      string    `json:"mode" validate:"required,oneof=test learn"`
+	Size      int       `json:"size" validate:"omitempty,min=1,max=100"`
+	Direction string    `json:"direction" validate:"omitempty,oneof=forward reverse mix"`
+	Choices   int       `json:"choices" validate:"omitempty,min=2,max=6"`
+	From      string    `json:"from" validate:"omitempty,language"`
+	To        string    `json:"to" validate:"omitempty,language"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+type QuizAnswerRequest struct {
+	UserID     string    `json:"user_id" validate:"omitempty,uuid"`
+	SessionID  string    `json:"session_id" validate:"required,uuid"`
+	WordID     string    `json:"word_id" validate:"required,uuid"`
+	Correct    bool      `json:"correct"`
+	Typo       bool      `json:"typo"`
+	LatencyMs  int64     `json:"latency_ms" validate:"min=0,max=3600000"`
+	AnsweredAt time.Time `json:"answered_at"`
+}
+
+type PhrasesRequest struct {
+	Limit int    `json:"limit" validate:"omitempty,min=1,max=50"`
+	Theme string `json:"theme" validate:"max=100"`
+}
+
+type CheckPhraseRequest struct {
+	PhraseID int    `json:"phrase_id" validate:"required,min=1"`
+	Answer   string `json:"answer" validate:"max=500"`
+}
+
+type NextQuizPromptRequest struct {
+	UserID    string `json:"user_id" validate:"omitempty,uuid"`
+	SessionID string `json:"session_id" validate:"required,uuid"`
+}
+
+// CheckQuizAnswerRequest answers the prompt at Position, the one the last next call returned.
+type CheckQuizAnswerRequest struct {
+	UserID    string `json:"user_id" validate:"omitempty,uuid"`
+	SessionID string `json:"session_id" validate:"required,uuid"`
+	Position  int    `json:"position" validate:"required,min=1"`
+	Answer    string `json:"answer" validate:"max=200"`
+}
+
+type FinishQuizSessionRequest struct {
+	UserID     string    `json:"user_id" validate:"omitempty,uuid"`
+	SessionID  string    `json:"session_id" validate:"required,uuid"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+type StatsRequest struct {
+	UserID string `json:"user_id" validate:"omitempty,uuid"`
+	Days   string `json:"days" validate:"omitempty,limit=365"`
+	From   string `json:"from" validate:"omitempty,language"`
+	To     string `json:"to" validate:"omitempty,language"`
 }
@@ -0,0 +1,122 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"server/internal/apperrors"
+	"server/internal/domain/models"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	passwordMinLength = 8
+	// bcrypt ignores the bytes after 72.
+	passwordMaxLength = 72
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+
+		return name
+	})
+
+	v.RegisterValidation("password", isPassword)
+	v.RegisterValidation("language", func(fl validator.FieldLevel) bool {
+		return models.IsLanguage(fl.Field().String())
+	})
+	return v
+}
+
+// isPassword asks for 8 to 72 bytes with at least one letter and one digit.
+func isPassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if len(password) < passwordMinLength || len(password) > passwordMaxLength {
+		return false
+	}
+
+	hasLetter, hasDigit := false, false
+	for _, r := range password {
+		hasLetter = hasLetter || unicode.IsLetter(r)
+		hasDigit = hasDigit || unicode.IsDigit(r)
+	}
+
+	return hasLetter && hasDigit
+}
+
+// Validate checks the validate tags of a request, the cause of the returned error is apperrors.FieldErrors.
+func Validate(v interface{}) error {
+	if reflect.Indirect(reflect.ValueOf(v)).Kind() != reflect.Struct {
+		return nil
+	}
+
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return apperrors.ValidationErr.AppendMessage(err)
+	}
+
+	fieldErrs := apperrors.FieldErrors{}
+	for _, fieldErr := range validationErrs {
+		fieldErrs = append(fieldErrs, &apperrors.FieldError{Field: fieldPath(fieldErr), Message: fieldMessage(fieldErr)})
+	}
+
+	return apperrors.ValidationErr.AppendMessage(fieldErrs)
+}
+
+// fieldPath drops the struct name, library_phrases[0].english instead of LibraryWordRequest.library_phrases[0].english.
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.Index(namespace, "."); i != -1 {
+		return namespace[i+1:]
+	}
+
+	return namespace
+}
+
+func fieldMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email"
+	case "password":
+		return fmt.Sprintf("must be %v to %v characters with a letter and a digit", passwordMinLength, passwordMaxLength)
+	case "language":
+		return fmt.Sprintf("must be one of %v", strings.Join(models.Languages, " "))
+	case "uuid":
+		return "must be a UUID"
+	case "oneof":
+		return fmt.Sprintf("must be one of %v", fieldErr.Param())
+	case "min":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %v characters", fieldErr.Param())
+		}
+
+		return fmt.Sprintf("must be at least %v", fieldErr.Param())
+	case "max":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %v characters", fieldErr.Param())
+		}
+
+		return fmt.Sprintf("must be at most %v", fieldErr.Param())
+	case "nefield":
+		return fmt.Sprintf("must differ from %v", strings.ToLower(fieldErr.Param()))
+	}
+
+	return fmt.Sprintf("failed the %v check", fieldErr.Tag())
+}
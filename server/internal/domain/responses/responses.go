@@ -1,5 +1,7 @@
 package responses
 
+import "time"
+
 type CreateUserResponse struct {
 	UserId string `json:"user_id"`
 }
@@ -8,16 +10,42 @@ type Result struct {
 	Answer string `json:"result"`
 }
 
+// GetTranslResponse fills English and Russian for the en <-> ru pairs the old clients read.
 type GetTranslResponse struct {
-	English string `json:"english"`
-	Russian string `json:"russian"`
+	English     string `json:"english"`
+	Russian     string `json:"russian"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Word        string `json:"word"`
+	Translation string `json:"translation"`
+}
+
+type WordsPageResp struct {
+	Words      []*WordResp `json:"words"`
+	Total      int64       `json:"total"`
+	NextCursor string      `json:"next_cursor"`
+}
+
+type SearchTranslResponse struct {
+	Results    []*ScoredTranslResponse `json:"results"`
+	DidYouMean []string                `json:"did_you_mean"`
+}
+
+type ScoredTranslResponse struct {
+	GetTranslResponse
+	Score float64 `json:"score"`
+}
+
+type AddLanguagePairResponse struct {
+	Added int `json:"added"`
 }
 
 type LoginResponse struct {
-	Token        string `json:"token"`
-	TokenType    string `json:"token_type"`
-	ExpiresIn    string `json:"expires_in"`
-	RefreshToken string `json:"refresh_token"`
+	Token            string `json:"token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        string `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn string `json:"refresh_expires_in"`
 }
 
 type WordResp struct {
@@ -25,4 +53,165 @@ type WordResp struct {
 	English       string `json:"english"`
 	Russian       string `json:"russian"`
 	PartsOfSpeech string `json:"part_of_speech"`
+	LanguageFrom  string `json:"language_from"`
+	LanguageTo    string `json:"language_to"`
+}
+
+// WordsBatchResp has a result for every operation in the order of the request, Applied counts the applied ones.
+type WordsBatchResp struct {
+	Applied int                  `json:"applied"`
+	Results []*WordOperationResp `json:"results"`
+}
+
+type WordOperationResp struct {
+	WordID string `json:"word_id"`
+	Action string `json:"action"`
+	Status string `json:"status"`
+}
+
+type ReviewResp struct {
+	WordID       string    `json:"word_id"`
+	EaseFactor   float64   `json:"ease_factor"`
+	Interval     int       `json:"interval"`
+	Repetitions  int       `json:"repetitions"`
+	NextReviewAt time.Time `json:"next_review_at"`
+}
+
+type LibraryWordResp struct {
+	ID            int           `json:"id"`
+	English       string        `json:"english"`
+	Russian       string        `json:"russian"`
+	Theme         string        `json:"theme"`
+	PartsOfSpeech string        `json:"part_of_speech"`
+	Phrases       []*PhraseResp `json:"library_phrases"`
+	Exceptions    string        `json:"exceptions"`
+}
+
+type PhraseResp struct {
+	ID      int    `json:"id"`
+	English string `json:"english"`
+	Russian string `json:"russian"`
+}
+
+type ImportLibraryResponse struct {
+	Imported int `json:"imported"`
+}
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// ProblemResponse is the RFC 7807 problem document of every error, Code is stable for the clients.
+type ProblemResponse struct {
+	Type   string                `json:"type"`
+	Title  string                `json:"title"`
+	Status int                   `json:"status"`
+	Code   string                `json:"code"`
+	Detail string                `json:"detail"`
+	Errors []*FieldErrorResponse `json:"errors,omitempty"`
+}
+
+type FieldErrorResponse struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type QuizSessionResp struct {
+	ID         string     `json:"id"`
+	Mode       string     `json:"mode"`
+	Direction  string     `json:"direction,omitempty"`
+	Size       int        `json:"size,omitempty"`
+	Choices    int        `json:"choices,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// QuizPromptResp is the next question of a session without its answer, Done is set when nothing is left to ask.
+// Choices are the options of a multiple choice session, one of them is the answer.
+type QuizPromptResp struct {
+	SessionID      string   `json:"session_id"`
+	Done           bool     `json:"done"`
+	Position       int      `json:"position,omitempty"`
+	WordID         string   `json:"word_id,omitempty"`
+	Prompt         string   `json:"prompt,omitempty"`
+	Language       string   `json:"language,omitempty"`
+	AnswerLanguage string   `json:"answer_language,omitempty"`
+	Theme          string   `json:"theme,omitempty"`
+	PartsOfSpeech  string   `json:"part_of_speech,omitempty"`
+	Choices        []string `json:"choices,omitempty"`
+	Remaining      int64    `json:"remaining"`
+	Total          int64    `json:"total"`
+}
+
+// PhrasePromptResp asks a library phrase in Russian, the English text is only revealed by the check.
+type PhrasePromptResp struct {
+	ID     int    `json:"id"`
+	Prompt string `json:"prompt"`
+}
+
+type PhraseVerdictResp struct {
+	PhraseID int    `json:"phrase_id"`
+	Correct  bool   `json:"correct"`
+	Typo     bool   `json:"typo"`
+	Answer   string `json:"answer"`
+	Expected string `json:"expected"`
+}
+
+type QuizVerdictResp struct {
+	Position  int    `json:"position"`
+	WordID    string `json:"word_id"`
+	Correct   bool   `json:"correct"`
+	Typo      bool   `json:"typo"`
+	Answer    string `json:"answer"`
+	Expected  string `json:"expected"`
+	Remaining int64  `json:"remaining"`
+}
+
+type QuizSummaryResp struct {
+	QuizSessionResp
+	Right           int64   `json:"right"`
+	Wrong           int64   `json:"wrong"`
+	Typos           int64   `json:"typos"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+type StatsResp struct {
+	CurrentStreak  int                 `json:"current_streak"`
+	LongestStreak  int                 `json:"longest_streak"`
+	Answers        int64               `json:"answers"`
+	Accuracy       float64             `json:"accuracy"`
+	Daily          []*DailyStatsResp   `json:"daily"`
+	LearnedPerWeek []*WeeklyStatsResp  `json:"learned_per_week"`
+	HardestWords   []*HardWordResp     `json:"hardest_words"`
+	Themes         []*ThemeMasteryResp `json:"themes"`
+}
+
+type DailyStatsResp struct {
+	Date     string  `json:"date"`
+	Answers  int64   `json:"answers"`
+	Correct  int64   `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+type WeeklyStatsResp struct {
+	WeekStart string `json:"week_start"`
+	Learned   int64  `json:"learned"`
+}
+
+type HardWordResp struct {
+	WordID    string  `json:"word_id"`
+	English   string  `json:"english"`
+	Russian   string  `json:"russian"`
+	Answers   int64   `json:"answers"`
+	Wrong     int64   `json:"wrong"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+type ThemeMasteryResp struct {
+	Theme    string  `json:"theme"`
+	Words    int64   `json:"words"`
+	Learned  int64   `json:"learned"`
+	Mastery  float64 `json:"mastery"`
+	Answers  int64   `json:"answers"`
+	Accuracy float64 `json:"accuracy"`
 }
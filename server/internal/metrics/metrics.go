@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "translator"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	RequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "code"})
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	TranslationsServed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "translations_served_total",
+		Help:      "Translations returned by translate and search.",
+	})
+	WordsAddedToLearn = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "words_added_to_learn_total",
+		Help:      "Words added to the learn list.",
+	})
+	WordsMovedToLearned = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "words_moved_to_learned_total",
+		Help:      "Words moved to the learned list.",
+	})
+	ReviewsAnswered = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reviews_answered_total",
+		Help:      "Review answers graded by the SM-2 scheduler.",
+	})
+	QuizAnswersChecked = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quiz_answers_checked_total",
+		Help:      "Quiz answers graded by the server by verdict.",
+	}, []string{"verdict"})
+	UsersCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "users_created_total",
+		Help:      "Registered users.",
+	})
+	RequestsLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_limited_total",
+		Help:      "Requests answered with 429 by limit.",
+	}, []string{"limit"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RequestsTotal, RequestDuration,
+		TranslationsServed, WordsAddedToLearn, WordsMovedToLearned, ReviewsAnswered, QuizAnswersChecked, UsersCreated,
+		RequestsLimited,
+	)
+}
+
+// RegisterDBStats exposes the connection pool stats of db, it is called once the database is set up.
+func RegisterDBStats(db *sql.DB) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,290 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"server/internal/apperrors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey is the pg_advisory_lock key, replicas starting together wait for each other.
+const lockKey = 20240101
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migrator interface {
+	Up(ctx context.Context) (int, error)
+	Down(ctx context.Context, steps int) (int, error)
+	Status(ctx context.Context) ([]*Status, error)
+}
+
+// Status is a migration of the binary, AppliedAt is nil until it is applied.
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+type migrator struct {
+	db         *gorm.DB
+	log        *logrus.Logger
+	migrations []*migration
+}
+
+func NewMigrator(db *gorm.DB, log *logrus.Logger) (Migrator, error) {
+	migrations, err := load()
+	if err != nil {
+		appErr := apperrors.LoadMigrationsErr.AppendMessage(err)
+		log.Error(appErr)
+		return nil, appErr
+	}
+
+	return &migrator{db: db, log: log, migrations: migrations}, nil
+}
+
+// load pairs the embedded up and down files by version.
+func load() ([]*migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %v", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := files.ReadFile("sql/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: match[2]}
+			byVersion[version] = m
+		}
+
+		if m.name != match[2] {
+			return nil, fmt.Errorf("migration %v has two names %v and %v", version, m.name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.up = string(body)
+		} else {
+			m.down = string(body)
+		}
+	}
+
+	migrations := []*migration{}
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %v_%v needs both up and down files", m.version, m.name)
+		}
+
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in order, each one in its own transaction.
+func (mg *migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := mg.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := mg.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range mg.migrations {
+			if _, ok := versions[m.version]; ok {
+				continue
+			}
+
+			mg.log.Infof("Applying migration %v_%v", m.version, m.name)
+			err := mg.apply(ctx, conn, m.up,
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, NOW())", m.version, m.name)
+			if err != nil {
+				return fmt.Errorf("migration %v_%v: %w", m.version, m.name, err)
+			}
+
+			applied++
+		}
+
+		return nil
+	})
+	if err != nil {
+		appErr := apperrors.MigrateUpErr.AppendMessage(err)
+		mg.log.Error(appErr)
+		return applied, appErr
+	}
+
+	return applied, nil
+}
+
+// Down rolls back the last applied steps migrations.
+func (mg *migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+	err := mg.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := mg.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(mg.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			m := mg.migrations[i]
+			if _, ok := versions[m.version]; !ok {
+				continue
+			}
+
+			mg.log.Infof("Reverting migration %v_%v", m.version, m.name)
+			err := mg.apply(ctx, conn, m.down, "DELETE FROM schema_migrations WHERE version = $1", m.version)
+			if err != nil {
+				return fmt.Errorf("migration %v_%v: %w", m.version, m.name, err)
+			}
+
+			reverted++
+		}
+
+		return nil
+	})
+	if err != nil {
+		appErr := apperrors.MigrateDownErr.AppendMessage(err)
+		mg.log.Error(appErr)
+		return reverted, appErr
+	}
+
+	return reverted, nil
+}
+
+func (mg *migrator) Status(ctx context.Context) ([]*Status, error) {
+	statuses := []*Status{}
+	err := mg.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := mg.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range mg.migrations {
+			status := &Status{Version: m.version, Name: m.name}
+			if appliedAt, ok := versions[m.version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+	if err != nil {
+		appErr := apperrors.MigrateStatusErr.AppendMessage(err)
+		mg.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return statuses, nil
+}
+
+// withLock holds the session advisory lock on one connection of the pool while fn runs.
+func (mg *migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	sqlDB, err := mg.db.DB()
+	if err != nil {
+		return err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return err
+	}
+
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			mg.log.Error(err)
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func (mg *migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	versions := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		versions[version] = appliedAt
+	}
+
+	return versions, rows.Err()
+}
+
+// apply runs the migration script and records it in schema_migrations in one transaction.
+func (mg *migrator) apply(ctx context.Context, conn *sql.Conn, script string, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
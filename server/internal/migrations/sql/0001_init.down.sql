@@ -0,0 +1 @@
+DROP TABLE IF EXISTS user_learned, user_learn, user_words, words, users, library_phrases, phrases, libraries;
@@ -0,0 +1,72 @@
+-- The schema AutoMigrate created before the versioned migrations, existing tables are kept.
+CREATE TABLE IF NOT EXISTS libraries (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	english text,
+	russian text,
+	theme text,
+	parts_of_speech text,
+	exceptions text
+);
+CREATE INDEX IF NOT EXISTS idx_libraries_deleted_at ON libraries (deleted_at);
+
+CREATE TABLE IF NOT EXISTS phrases (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	english text,
+	russian text
+);
+CREATE INDEX IF NOT EXISTS idx_phrases_deleted_at ON phrases (deleted_at);
+
+CREATE TABLE IF NOT EXISTS library_phrases (
+	library_id bigint REFERENCES libraries (id),
+	phrase_id bigint REFERENCES phrases (id),
+	PRIMARY KEY (library_id, phrase_id)
+);
+
+CREATE TABLE IF NOT EXISTS users (
+	id text PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	email text,
+	name text,
+	last_name text,
+	password text,
+	role text
+);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS words (
+	id text PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	english text,
+	russian text,
+	theme text,
+	parts_of_speech text
+);
+CREATE INDEX IF NOT EXISTS idx_words_deleted_at ON words (deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_words (
+	user_id text REFERENCES users (id),
+	word_id text REFERENCES words (id),
+	PRIMARY KEY (user_id, word_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_learn (
+	user_id text REFERENCES users (id),
+	word_id text REFERENCES words (id),
+	PRIMARY KEY (user_id, word_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_learned (
+	user_id text REFERENCES users (id),
+	word_id text REFERENCES words (id),
+	PRIMARY KEY (user_id, word_id)
+);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS reviews (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	user_id text,
+	word_id text,
+	ease_factor decimal,
+	"interval" bigint,
+	repetitions bigint,
+	next_review_at timestamptz
+);
+
+-- AutoMigrate created the ids as uuid, they are compared with the text ids of users and words.
+ALTER TABLE reviews ALTER COLUMN user_id TYPE text, ALTER COLUMN word_id TYPE text;
+
+CREATE INDEX IF NOT EXISTS idx_reviews_deleted_at ON reviews (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_user_word ON reviews (user_id, word_id);
+CREATE INDEX IF NOT EXISTS idx_reviews_next_review_at ON reviews (next_review_at);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti text PRIMARY KEY,
+	expires_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
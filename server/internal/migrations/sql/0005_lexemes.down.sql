@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS translations, lexemes;
+
+DROP INDEX IF EXISTS idx_words_language_from;
+DROP INDEX IF EXISTS idx_words_language_to;
+ALTER TABLE words DROP COLUMN IF EXISTS language_from, DROP COLUMN IF EXISTS language_to;
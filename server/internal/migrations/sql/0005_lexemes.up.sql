@@ -0,0 +1,61 @@
+CREATE TABLE IF NOT EXISTS lexemes (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	language text,
+	text text,
+	theme text,
+	parts_of_speech text
+);
+CREATE INDEX IF NOT EXISTS idx_lexemes_deleted_at ON lexemes (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lexemes_language_text ON lexemes (language, text);
+
+CREATE TABLE IF NOT EXISTS translations (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	source_id bigint REFERENCES lexemes (id),
+	target_id bigint REFERENCES lexemes (id),
+	library_id bigint
+);
+CREATE INDEX IF NOT EXISTS idx_translations_deleted_at ON translations (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_translations_source_target ON translations (source_id, target_id);
+CREATE INDEX IF NOT EXISTS idx_translations_library_id ON translations (library_id);
+
+ALTER TABLE words
+	ADD COLUMN IF NOT EXISTS language_from text DEFAULT 'ru',
+	ADD COLUMN IF NOT EXISTS language_to text DEFAULT 'en';
+CREATE INDEX IF NOT EXISTS idx_words_language_from ON words (language_from);
+CREATE INDEX IF NOT EXISTS idx_words_language_to ON words (language_to);
+
+-- Every en-ru Library entry is mirrored into lexemes linked in both directions.
+INSERT INTO lexemes (created_at, updated_at, language, text, theme, parts_of_speech)
+SELECT DISTINCT ON (entries.language, entries.text) NOW(), NOW(), entries.language, entries.text, entries.theme, entries.parts_of_speech
+FROM (
+	SELECT id, 'en' AS language, english AS text, theme, parts_of_speech FROM libraries
+	WHERE deleted_at IS NULL AND english <> '' AND russian <> ''
+	UNION ALL
+	SELECT id, 'ru' AS language, russian AS text, theme, parts_of_speech FROM libraries
+	WHERE deleted_at IS NULL AND english <> '' AND russian <> ''
+) AS entries
+ORDER BY entries.language, entries.text, entries.id
+ON CONFLICT (language, text) DO NOTHING;
+
+INSERT INTO translations (created_at, updated_at, source_id, target_id, library_id)
+SELECT NOW(), NOW(), pairs.source_id, pairs.target_id, pairs.library_id
+FROM (
+	SELECT english.id AS source_id, russian.id AS target_id, libraries.id AS library_id
+	FROM libraries
+	JOIN lexemes AS english ON english.language = 'en' AND english.text = libraries.english
+	JOIN lexemes AS russian ON russian.language = 'ru' AND russian.text = libraries.russian
+	WHERE libraries.deleted_at IS NULL
+	UNION ALL
+	SELECT russian.id AS source_id, english.id AS target_id, libraries.id AS library_id
+	FROM libraries
+	JOIN lexemes AS english ON english.language = 'en' AND english.text = libraries.english
+	JOIN lexemes AS russian ON russian.language = 'ru' AND russian.text = libraries.russian
+	WHERE libraries.deleted_at IS NULL
+) AS pairs
+ON CONFLICT (source_id, target_id) DO NOTHING;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_lexemes_text_trgm;
@@ -0,0 +1,16 @@
+-- Without pg_trgm the translations are ranked in Go, the migration doesn't fail on it.
+DO $$
+BEGIN
+	CREATE EXTENSION IF NOT EXISTS pg_trgm;
+EXCEPTION WHEN insufficient_privilege OR undefined_file THEN
+	RAISE NOTICE 'pg_trgm is not available, translations are ranked in Go';
+END
+$$;
+
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm') THEN
+		CREATE INDEX IF NOT EXISTS idx_lexemes_text_trgm ON lexemes USING gin (text gin_trgm_ops);
+	END IF;
+END
+$$;
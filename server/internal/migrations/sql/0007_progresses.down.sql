@@ -0,0 +1,42 @@
+-- The shared words can't be split back into copies, the lists reference the shared words.
+CREATE TABLE IF NOT EXISTS user_words (
+	user_id text REFERENCES users (id),
+	word_id text REFERENCES words (id),
+	PRIMARY KEY (user_id, word_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_learn (
+	user_id text REFERENCES users (id),
+	word_id text REFERENCES words (id),
+	PRIMARY KEY (user_id, word_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_learned (
+	user_id text REFERENCES users (id),
+	word_id text REFERENCES words (id),
+	PRIMARY KEY (user_id, word_id)
+);
+
+INSERT INTO user_learn (user_id, word_id)
+SELECT user_id, word_id FROM progresses WHERE in_learn AND deleted_at IS NULL
+ON CONFLICT DO NOTHING;
+
+INSERT INTO user_learned (user_id, word_id)
+SELECT user_id, word_id FROM progresses WHERE learned AND deleted_at IS NULL
+ON CONFLICT DO NOTHING;
+
+INSERT INTO user_words (user_id, word_id)
+SELECT users.id, words.id
+FROM users
+CROSS JOIN words
+WHERE words.language_from = 'ru' AND words.language_to = 'en' AND words.deleted_at IS NULL
+	AND NOT EXISTS (
+		SELECT 1 FROM progresses
+		WHERE progresses.user_id = users.id AND progresses.word_id = words.id AND progresses.learned
+	)
+ON CONFLICT DO NOTHING;
+
+DROP TABLE IF EXISTS progresses;
+
+DROP INDEX IF EXISTS idx_words_library_id;
+ALTER TABLE words DROP COLUMN IF EXISTS library_id;
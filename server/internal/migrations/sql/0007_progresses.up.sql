@@ -0,0 +1,71 @@
+-- The per-user copies of the library become one shared word list with lazy per-user progress.
+ALTER TABLE words ADD COLUMN IF NOT EXISTS library_id bigint REFERENCES libraries (id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_words_library_id ON words (library_id);
+
+CREATE TABLE IF NOT EXISTS progresses (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	user_id text,
+	word_id text REFERENCES words (id),
+	in_learn boolean,
+	learned boolean
+);
+CREATE INDEX IF NOT EXISTS idx_progresses_deleted_at ON progresses (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_progresses_user_word ON progresses (user_id, word_id);
+CREATE INDEX IF NOT EXISTS idx_progresses_in_learn ON progresses (in_learn);
+CREATE INDEX IF NOT EXISTS idx_progresses_learned ON progresses (learned);
+
+-- Duplicated words collapse into the oldest copy.
+CREATE TEMP TABLE word_canonical ON COMMIT DROP AS
+SELECT id, FIRST_VALUE(id) OVER (
+	PARTITION BY language_from, language_to, english, russian ORDER BY created_at, id
+) AS canonical_id
+FROM words;
+
+INSERT INTO progresses (created_at, updated_at, user_id, word_id, in_learn, learned)
+SELECT NOW(), NOW(), lists.user_id, word_canonical.canonical_id, BOOL_OR(lists.in_learn), BOOL_OR(lists.learned)
+FROM (
+	SELECT user_id, word_id, TRUE AS in_learn, FALSE AS learned FROM user_learn
+	UNION ALL
+	SELECT user_id, word_id, FALSE AS in_learn, TRUE AS learned FROM user_learned
+) AS lists
+JOIN word_canonical ON word_canonical.id = lists.word_id
+GROUP BY lists.user_id, word_canonical.canonical_id
+ON CONFLICT DO NOTHING;
+
+-- A user keeps the latest review of the collapsed copies.
+DELETE FROM reviews WHERE id IN (
+	SELECT id FROM (
+		SELECT reviews.id, ROW_NUMBER() OVER (
+			PARTITION BY reviews.user_id, word_canonical.canonical_id ORDER BY reviews.updated_at DESC
+		) AS position
+		FROM reviews
+		JOIN word_canonical ON word_canonical.id = reviews.word_id
+	) AS ranked
+	WHERE position > 1
+);
+
+UPDATE reviews SET word_id = word_canonical.canonical_id
+FROM word_canonical
+WHERE reviews.word_id = word_canonical.id AND word_canonical.id <> word_canonical.canonical_id;
+
+DROP TABLE IF EXISTS user_words, user_learn, user_learned;
+
+DELETE FROM words USING word_canonical
+WHERE words.id = word_canonical.id AND word_canonical.id <> word_canonical.canonical_id;
+
+-- Every Library entry gets its ru -> en word.
+UPDATE words SET library_id = libraries.id
+FROM libraries
+WHERE words.language_from = 'ru' AND words.language_to = 'en' AND words.library_id IS NULL
+	AND words.english = libraries.english AND words.russian = libraries.russian
+	AND libraries.deleted_at IS NULL
+	AND NOT EXISTS (SELECT 1 FROM words AS linked WHERE linked.library_id = libraries.id);
+
+INSERT INTO words (id, created_at, updated_at, english, russian, theme, parts_of_speech, language_from, language_to, library_id)
+SELECT gen_random_uuid(), NOW(), NOW(), libraries.english, libraries.russian, libraries.theme, libraries.parts_of_speech, 'ru', 'en', libraries.id
+FROM libraries
+WHERE libraries.deleted_at IS NULL
+	AND NOT EXISTS (SELECT 1 FROM words WHERE words.library_id = libraries.id);
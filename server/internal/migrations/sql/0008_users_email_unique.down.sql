@@ -0,0 +1,2 @@
+-- The lower-cased and renamed emails stay as they are.
+DROP INDEX IF EXISTS idx_users_email;
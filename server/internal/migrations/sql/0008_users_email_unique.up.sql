@@ -0,0 +1,9 @@
+-- Emails are stored in lower case, the login looks them up the same way.
+UPDATE users SET email = lower(trim(email)) WHERE email <> lower(trim(email));
+
+-- An email registered twice keeps its oldest account, the newer ones get a placeholder the index accepts.
+UPDATE users SET email = users.id || '.duplicate.' || users.email
+FROM users AS first
+WHERE first.email = users.email AND (first.created_at, first.id) < (users.created_at, users.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS quiz_sessions (
+	id text PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	user_id text REFERENCES users (id),
+	mode text,
+	started_at timestamptz,
+	finished_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_quiz_sessions_deleted_at ON quiz_sessions (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_quiz_sessions_user_id ON quiz_sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS quiz_answers (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	session_id text REFERENCES quiz_sessions (id),
+	user_id text REFERENCES users (id),
+	word_id text REFERENCES words (id),
+	correct boolean,
+	typo boolean,
+	latency_ms bigint,
+	answered_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_quiz_answers_deleted_at ON quiz_answers (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_quiz_answers_session_id ON quiz_answers (session_id);
+CREATE INDEX IF NOT EXISTS idx_quiz_answers_word_id ON quiz_answers (word_id);
+CREATE INDEX IF NOT EXISTS idx_quiz_answers_user_answered ON quiz_answers (user_id, answered_at);
@@ -0,0 +1,17 @@
+-- The sessions reported by the clients before keep an empty direction.
+ALTER TABLE quiz_sessions ADD COLUMN IF NOT EXISTS direction text;
+
+CREATE TABLE IF NOT EXISTS quiz_items (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	session_id text REFERENCES quiz_sessions (id),
+	position bigint,
+	word_id text REFERENCES words (id),
+	reverse boolean,
+	prompted_at timestamptz,
+	answered_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_quiz_items_deleted_at ON quiz_items (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_quiz_items_session_position ON quiz_items (session_id, position);
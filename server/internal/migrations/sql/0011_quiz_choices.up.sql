@@ -0,0 +1 @@
+ALTER TABLE quiz_sessions ADD COLUMN IF NOT EXISTS choices bigint NOT NULL DEFAULT 0;
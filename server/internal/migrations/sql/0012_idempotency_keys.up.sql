@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	id bigserial PRIMARY KEY,
+	user_id text NOT NULL,
+	key text NOT NULL,
+	fingerprint text NOT NULL,
+	status bigint NOT NULL DEFAULT 0,
+	content_type text NOT NULL DEFAULT '',
+	body bytea,
+	created_at timestamptz,
+	expires_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_keys_user_key ON idempotency_keys (user_id, key);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
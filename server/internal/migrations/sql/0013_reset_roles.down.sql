@@ -0,0 +1,2 @@
+-- The reset roles can't be told apart, they stay users. ADMIN_EMAILS grants the admins again on start.
+SELECT 1;
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN IF EXISTS language_from, DROP COLUMN IF EXISTS language_to;
@@ -0,0 +1,3 @@
+-- The pair a user has chosen, the lists without an explicit pair are scoped to it. Empty is the ru -> en pair.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS language_from text NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS language_to text NOT NULL DEFAULT '';
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"server/internal/apperrors"
+	"server/internal/domain/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RepoIdempotency interface {
+	ReserveKey(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error)
+	SaveResponse(ctx context.Context, key *models.IdempotencyKey) error
+	ReleaseKey(ctx context.Context, key *models.IdempotencyKey) error
+	DeleteExpired(ctx context.Context) error
+}
+
+type repoIdempotency struct {
+	db  *gorm.DB
+	log *logrus.Logger
+}
+
+func NewRepoIdempotency(db *gorm.DB, log *logrus.Logger) RepoIdempotency {
+	return &repoIdempotency{db: db, log: log}
+}
+
+// ReserveKey stores the key of a request which is about to run, it returns nil when the key is free.
+// A key already taken returns the stored one, an expired key is taken over, an in progress one whose lease has run out too.
+func (ri *repoIdempotency) ReserveKey(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	result := ri.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		appErr := apperrors.ReserveIdempotencyKeyErr.AppendMessage(result.Error)
+		ri.log.Error(appErr)
+		return nil, appErr
+	}
+
+	if result.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	stored := &models.IdempotencyKey{}
+	result = ri.db.WithContext(ctx).Where("user_id = ? AND key = ?", key.UserID, key.Key).Limit(1).Find(stored)
+	if result.Error != nil {
+		appErr := apperrors.ReserveIdempotencyKeyErr.AppendMessage(result.Error)
+		ri.log.Error(appErr)
+		return nil, appErr
+	}
+
+	// The first request has just released the key, the caller is told to retry.
+	if result.RowsAffected == 0 {
+		return &models.IdempotencyKey{UserID: key.UserID, Key: key.Key, Fingerprint: key.Fingerprint}, nil
+	}
+
+	if stored.ExpiresAt.After(time.Now()) {
+		return stored, nil
+	}
+
+	result = ri.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("id = ? AND expires_at = ?", stored.ID, stored.ExpiresAt).
+		Updates(map[string]interface{}{
+			"fingerprint":  key.Fingerprint,
+			"status":       0,
+			"content_type": "",
+			"body":         nil,
+			"created_at":   key.CreatedAt,
+			"expires_at":   key.ExpiresAt,
+		})
+	if result.Error != nil {
+		appErr := apperrors.ReserveIdempotencyKeyErr.AppendMessage(result.Error)
+		ri.log.Error(appErr)
+		return nil, appErr
+	}
+
+	if result.RowsAffected == 0 {
+		return &models.IdempotencyKey{UserID: key.UserID, Key: key.Key, Fingerprint: key.Fingerprint}, nil
+	}
+
+	key.ID = stored.ID
+	return nil, nil
+}
+
+// SaveResponse keeps the status and the body the first request has answered with until the key expires.
+func (ri *repoIdempotency) SaveResponse(ctx context.Context, key *models.IdempotencyKey) error {
+	err := ri.db.WithContext(ctx).Model(&models.IdempotencyKey{}).Where("id = ?", key.ID).
+		Updates(map[string]interface{}{"status": key.Status, "content_type": key.ContentType, "body": key.Body, "expires_at": key.ExpiresAt}).Error
+	if err != nil {
+		appErr := apperrors.SaveIdempotentResponseErr.AppendMessage(err)
+		ri.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+// ReleaseKey frees the key of a request which failed on the server side, a retry runs it again.
+func (ri *repoIdempotency) ReleaseKey(ctx context.Context, key *models.IdempotencyKey) error {
+	err := ri.db.WithContext(ctx).Where("id = ?", key.ID).Delete(&models.IdempotencyKey{}).Error
+	if err != nil {
+		appErr := apperrors.ReleaseIdempotencyKeyErr.AppendMessage(err)
+		ri.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+func (ri *repoIdempotency) DeleteExpired(ctx context.Context) error {
+	err := ri.db.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&models.IdempotencyKey{}).Error
+	if err != nil {
+		appErr := apperrors.DeleteExpiredIdempotencyKeysErr.AppendMessage(err)
+		ri.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package repositories
+
+import (
+	"context"
+	"server/internal/apperrors"
+	"server/internal/domain/models"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RepoLexemes interface {
+	GetTranslations(ctx context.Context, from string, text string, to string) ([]*models.Translation, error)
+	GetTranslationsLike(ctx context.Context, from string, text string, to string) ([]*models.Translation, error)
+	SearchCandidates(ctx context.Context, from string, text string, to string, limit int) ([]*models.Translation, error)
+	GetTranslationsByPair(ctx context.Context, from string, to string) ([]*models.Translation, error)
+	CreateTranslation(ctx context.Context, source *models.Lexeme, target *models.Lexeme) error
+}
+
+type repoLexemes struct {
+	db            *gorm.DB
+	log           *logrus.Logger
+	trigramMu     sync.Mutex
+	trigramProbed bool
+	trigram       bool
+}
+
+func NewRepoLexemes(db *gorm.DB, log *logrus.Logger) RepoLexemes {
+	return &repoLexemes{db: db, log: log}
+}
+
+func (rl *repoLexemes) GetTranslations(ctx context.Context, from string, text string, to string) ([]*models.Translation, error) {
+	translations, err := rl.findTranslations(ctx, from, to, "LOWER(lexemes.text) = LOWER(?)", text)
+	if err != nil {
+		appErr := apperrors.GetTranslationsErr.AppendMessage(err)
+		rl.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return translations, nil
+}
+
+func (rl *repoLexemes) GetTranslationsLike(ctx context.Context, from string, text string, to string) ([]*models.Translation, error) {
+	translations, err := rl.findTranslations(ctx, from, to, "lexemes.text ILIKE ?", "%"+escapeLike(text)+"%")
+	if err != nil {
+		appErr := apperrors.GetTranslationsLikeErr.AppendMessage(err)
+		rl.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return translations, nil
+}
+
+const (
+	// trigramThreshold is the lowest pg_trgm word similarity worth ranking, it is set for the search query only.
+	trigramThreshold = "0.3"
+	// trigramProbeTimeout bounds the probe of pg_trgm, it doesn't run on the context of a request.
+	trigramProbeTimeout = 5 * time.Second
+	// fallbackPrefixRunes is how much of the query a sense has to start with to be a candidate without pg_trgm,
+	// the typos after it are still ranked in Go.
+	fallbackPrefixRunes = 2
+)
+
+// likeEscaper escapes the LIKE wildcards of a user text, backslash is the default escape of Postgres.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func escapeLike(text string) string {
+	return likeEscaper.Replace(text)
+}
+
+// SearchCandidates narrows the pair down with pg_trgm, the query is matched against the closest part of the text,
+// a typo in one sense of a text with many still finds it. Without the extension the candidates are the texts
+// containing the query, then the senses starting like it. Both return limit candidates at most to be ranked in Go.
+func (rl *repoLexemes) SearchCandidates(ctx context.Context, from string, text string, to string, limit int) ([]*models.Translation, error) {
+	contains := "%" + escapeLike(text) + "%"
+	var translations []*models.Translation
+	var err error
+	if rl.hasTrigram() {
+		err = rl.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT set_config('pg_trgm.word_similarity_threshold', ?, true)", trigramThreshold).Error; err != nil {
+				return err
+			}
+
+			return translationsOfPair(tx, from, to).
+				Where("lexemes.text ILIKE ? OR ? <% lexemes.text", contains, text).
+				Clauses(clause.OrderBy{Expression: clause.Expr{SQL: "word_similarity(?, lexemes.text) DESC", Vars: []interface{}{text}}}).
+				Limit(limit).
+				Find(&translations).Error
+		})
+	} else {
+		prefix := escapeLike(leadingRunes(text, fallbackPrefixRunes)) + "%"
+		err = translationsOfPair(rl.db.WithContext(ctx), from, to).
+			Where("lexemes.text ILIKE ? OR lexemes.text ILIKE ? OR lexemes.text ILIKE ?", contains, prefix, "%, "+prefix).
+			Clauses(clause.OrderBy{Expression: clause.Expr{SQL: "lexemes.text ILIKE ? DESC, lexemes.text", Vars: []interface{}{contains}}}).
+			Limit(limit).
+			Find(&translations).Error
+	}
+
+	if err != nil {
+		appErr := apperrors.SearchCandidatesErr.AppendMessage(err)
+		rl.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return translations, nil
+}
+
+// leadingRunes cuts the text to its first n runes.
+func leadingRunes(text string, n int) string {
+	for i := range text {
+		if n == 0 {
+			return text[:i]
+		}
+
+		n--
+	}
+
+	return text
+}
+
+// hasTrigram probes pg_trgm once, a probe which fails is tried again by the next search.
+func (rl *repoLexemes) hasTrigram() bool {
+	rl.trigramMu.Lock()
+	defer rl.trigramMu.Unlock()
+	if rl.trigramProbed {
+		return rl.trigram
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), trigramProbeTimeout)
+	defer cancel()
+
+	var trigram bool
+	err := rl.db.WithContext(ctx).Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm')").Scan(&trigram).Error
+	if err != nil {
+		rl.log.Warn(apperrors.SearchCandidatesErr.AppendMessage(err))
+		return false
+	}
+
+	rl.trigram = trigram
+	rl.trigramProbed = true
+	if !rl.trigram {
+		rl.log.Warn("pg_trgm isn't installed, translations are ranked in Go")
+	}
+
+	return rl.trigram
+}
+
+func (rl *repoLexemes) GetTranslationsByPair(ctx context.Context, from string, to string) ([]*models.Translation, error) {
+	translations, err := rl.findTranslations(ctx, from, to, "TRUE")
+	if err != nil {
+		appErr := apperrors.GetTranslationsByPairErr.AppendMessage(err)
+		rl.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return translations, nil
+}
+
+func (rl *repoLexemes) findTranslations(ctx context.Context, from string, to string, sourceQuery string, args ...interface{}) ([]*models.Translation, error) {
+	var translations []*models.Translation
+	err := translationsOfPair(rl.db.WithContext(ctx), from, to).
+		Where(sourceQuery, args...).
+		Order("lexemes.text").
+		Find(&translations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return translations, nil
+}
+
+// translationsOfPair reads the translations of the pair with both lexemes, the source lexeme is joined as lexemes.
+func translationsOfPair(db *gorm.DB, from string, to string) *gorm.DB {
+	return db.
+		Preload("Source").
+		Preload("Target").
+		Joins("JOIN lexemes ON lexemes.id = translations.source_id AND lexemes.deleted_at IS NULL").
+		Joins("JOIN lexemes AS targets ON targets.id = translations.target_id AND targets.deleted_at IS NULL").
+		Where("lexemes.language = ? AND targets.language = ?", from, to)
+}
+
+func (rl *repoLexemes) CreateTranslation(ctx context.Context, source *models.Lexeme, target *models.Lexeme) error {
+	err := rl.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return linkLexemes(tx, source, target, nil)
+	})
+	if err != nil {
+		appErr := apperrors.CreateTranslationErr.AppendMessage(err)
+		rl.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+// syncLibraryTranslations replaces the translations mirroring the Library entry.
+func syncLibraryTranslations(tx *gorm.DB, word *models.Library) error {
+	if err := deleteLibraryTranslations(tx, word.ID); err != nil {
+		return err
+	}
+
+	if word.English == "" || word.Russian == "" {
+		return nil
+	}
+
+	english := &models.Lexeme{Language: models.LanguageEnglish, Text: word.English, Theme: word.Theme, PartsOfSpeech: word.PartsOfSpeech}
+	russian := &models.Lexeme{Language: models.LanguageRussian, Text: word.Russian, Theme: word.Theme, PartsOfSpeech: word.PartsOfSpeech}
+	libraryID := word.ID
+	return linkLexemes(tx, english, russian, &libraryID)
+}
+
+func deleteLibraryTranslations(tx *gorm.DB, libraryID int) error {
+	return tx.Unscoped().Where("library_id = ?", libraryID).Delete(&models.Translation{}).Error
+}
+
+// linkLexemes finds or creates both lexemes and links them in both directions.
+func linkLexemes(tx *gorm.DB, source *models.Lexeme, target *models.Lexeme, libraryID *int) error {
+	for _, lexeme := range []*models.Lexeme{source, target} {
+		err := tx.Where(models.Lexeme{Language: lexeme.Language, Text: lexeme.Text}).
+			Attrs(models.Lexeme{Theme: lexeme.Theme, PartsOfSpeech: lexeme.PartsOfSpeech}).
+			FirstOrCreate(lexeme).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	translations := []*models.Translation{
+		{SourceID: source.ID, TargetID: target.ID, LibraryID: libraryID},
+		{SourceID: target.ID, TargetID: source.ID, LibraryID: libraryID},
+	}
+
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Omit("Source", "Target").Create(&translations).Error
+}
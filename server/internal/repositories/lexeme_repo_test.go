@@ -0,0 +1,48 @@
+package repositories
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "plain", text: "house", want: "house"},
+		{name: "percent", text: "100%", want: `100\%`},
+		{name: "underscore", text: "a_b", want: `a\_b`},
+		{name: "backslash", text: `a\b`, want: `a\\b`},
+		{name: "only wildcards", text: `%_\`, want: `\%\_\\`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeLike(tt.text); got != tt.want {
+				t.Errorf("escapeLike(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeadingRunes(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		n    int
+		want string
+	}{
+		{name: "longer text", text: "house", n: 2, want: "ho"},
+		{name: "cyrillic", text: "дом", n: 2, want: "до"},
+		{name: "shorter text", text: "д", n: 2, want: "д"},
+		{name: "exact length", text: "ab", n: 2, want: "ab"},
+		{name: "empty", text: "", n: 2, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := leadingRunes(tt.text, tt.n); got != tt.want {
+				t.Errorf("leadingRunes(%q, %v) = %q, want %q", tt.text, tt.n, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,457 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"server/internal/apperrors"
+	"server/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AnswerCounts sums up the answers of a session.
+type AnswerCounts struct {
+	Answers int64
+	Correct int64
+	Typos   int64
+}
+
+// ItemCounts counts the prompts of a session, the requeued ones included.
+type ItemCounts struct {
+	Items     int64
+	Remaining int64
+}
+
+type DailyStats struct {
+	Day     time.Time
+	Answers int64
+	Correct int64
+}
+
+// WeeklyStats counts the words moved to learned in the week starting on Week.
+type WeeklyStats struct {
+	Week    time.Time
+	Learned int64
+}
+
+type WordStats struct {
+	WordID  string
+	English string
+	Russian string
+	Answers int64
+	Wrong   int64
+}
+
+type ThemeStats struct {
+	Theme   string
+	Words   int64
+	Learned int64
+	Answers int64
+	Correct int64
+}
+
+type RepoQuiz interface {
+	CreateSession(ctx context.Context, session *models.QuizSession) error
+	GetSession(ctx context.Context, id *uuid.UUID) (*models.QuizSession, error)
+	SaveSession(ctx context.Context, session *models.QuizSession) error
+	CreateAnswer(ctx context.Context, answer *models.QuizAnswer) error
+	GetNextItem(ctx context.Context, sessionID *uuid.UUID) (*models.QuizItem, error)
+	MarkItemPrompted(ctx context.Context, item *models.QuizItem) error
+	AnswerItem(ctx context.Context, item *models.QuizItem, answer *models.QuizAnswer, requeue bool, op *models.WordOperation, schedule func(review *models.Review)) error
+	CountItems(ctx context.Context, sessionID *uuid.UUID) (*ItemCounts, error)
+	GetAcceptedAnswers(ctx context.Context, word *models.Word, reverse bool) ([]string, error)
+	GetDistractors(ctx context.Context, word *models.Word, reverse bool, seed string, limit int) ([]string, error)
+	CountAnswers(ctx context.Context, sessionID *uuid.UUID) (*AnswerCounts, error)
+	GetAnswerDays(ctx context.Context, userID *uuid.UUID) ([]time.Time, error)
+	GetDailyStats(ctx context.Context, userID *uuid.UUID, since time.Time) ([]*DailyStats, error)
+	GetLearnedPerWeek(ctx context.Context, userID *uuid.UUID, since time.Time) ([]*WeeklyStats, error)
+	GetHardestWords(ctx context.Context, userID *uuid.UUID, limit int) ([]*WordStats, error)
+	GetThemeStats(ctx context.Context, userID *uuid.UUID, from string, to string) ([]*ThemeStats, error)
+}
+
+type repoQuiz struct {
+	db  *gorm.DB
+	log *logrus.Logger
+}
+
+func NewRepoQuiz(db *gorm.DB, log *logrus.Logger) RepoQuiz {
+	return &repoQuiz{db: db, log: log}
+}
+
+func (rq *repoQuiz) CreateSession(ctx context.Context, session *models.QuizSession) error {
+	err := rq.db.WithContext(ctx).Create(session).Error
+	if err != nil {
+		appErr := apperrors.CreateQuizSessionErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+// GetSession returns nil without error when there is no such session.
+func (rq *repoQuiz) GetSession(ctx context.Context, id *uuid.UUID) (*models.QuizSession, error) {
+	var sessions []*models.QuizSession
+	err := rq.db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(&sessions).Error
+	if err != nil {
+		appErr := apperrors.GetQuizSessionErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return nil, appErr
+	}
+
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+
+	return sessions[0], nil
+}
+
+func (rq *repoQuiz) SaveSession(ctx context.Context, session *models.QuizSession) error {
+	err := rq.db.WithContext(ctx).Omit(clause.Associations).Save(session).Error
+	if err != nil {
+		appErr := apperrors.SaveQuizSessionErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+func (rq *repoQuiz) CreateAnswer(ctx context.Context, answer *models.QuizAnswer) error {
+	err := rq.db.WithContext(ctx).Create(answer).Error
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		err = apperrors.WordNotFoundErr.AppendMessage(err)
+	}
+
+	if err != nil {
+		appErr := apperrors.CreateQuizAnswerErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+// GetNextItem returns the first unanswered item with its word, nil when the session has asked everything.
+func (rq *repoQuiz) GetNextItem(ctx context.Context, sessionID *uuid.UUID) (*models.QuizItem, error) {
+	var items []*models.QuizItem
+	err := rq.db.WithContext(ctx).Preload("Word").
+		Where("session_id = ? AND answered_at IS NULL", sessionID).
+		Order("position").
+		Limit(1).
+		Find(&items).Error
+	if err != nil {
+		appErr := apperrors.GetQuizItemErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return nil, appErr
+	}
+
+	if len(items) == 0 || items[0].Word == nil {
+		return nil, nil
+	}
+
+	return items[0], nil
+}
+
+// MarkItemPrompted keeps the time the item was shown first, the answer latency counts from it.
+func (rq *repoQuiz) MarkItemPrompted(ctx context.Context, item *models.QuizItem) error {
+	if item.PromptedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	err := rq.db.WithContext(ctx).Model(&models.QuizItem{}).
+		Where("id = ? AND prompted_at IS NULL", item.ID).
+		Update("prompted_at", now).Error
+	if err != nil {
+		appErr := apperrors.GetQuizItemErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return appErr
+	}
+
+	item.PromptedAt = &now
+	return nil
+}
+
+// AnswerItem closes the item and records the answer in one transaction, requeue asks the word again at the end of the session.
+// op, when not nil, is the change of the word list the answer makes, it is applied in the same transaction the way
+// a batch does it, so an answer is never recorded without it. An item answered in the meantime is reported as QuizPromptMismatchErr.
+func (rq *repoQuiz) AnswerItem(ctx context.Context, item *models.QuizItem, answer *models.QuizAnswer, requeue bool,
+	op *models.WordOperation, schedule func(review *models.Review)) error {
+	tx := rq.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		appErr := apperrors.AnswerQuizItemErr.AppendMessage(tx.Error)
+		rq.log.Error(appErr)
+		return appErr
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	result := tx.Model(&models.QuizItem{}).
+		Where("id = ? AND answered_at IS NULL", item.ID).
+		Update("answered_at", answer.AnsweredAt)
+	if result.Error != nil {
+		tx.Rollback()
+		appErr := apperrors.AnswerQuizItemErr.AppendMessage(result.Error)
+		rq.log.Error(appErr)
+		return appErr
+	}
+
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		appErr := apperrors.AnswerQuizItemErr.AppendMessage(&apperrors.QuizPromptMismatchErr)
+		rq.log.Error(appErr)
+		return appErr
+	}
+
+	if err := tx.Create(answer).Error; err != nil {
+		tx.Rollback()
+		appErr := apperrors.AnswerQuizItemErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return appErr
+	}
+
+	if requeue {
+		var last int
+		err := tx.Model(&models.QuizItem{}).
+			Select("COALESCE(MAX(position), 0)").
+			Where("session_id = ?", item.SessionID).
+			Scan(&last).Error
+		if err != nil {
+			tx.Rollback()
+			appErr := apperrors.AnswerQuizItemErr.AppendMessage(err)
+			rq.log.Error(appErr)
+			return appErr
+		}
+
+		again := &models.QuizItem{SessionID: item.SessionID, Position: last + 1, WordID: item.WordID, Reverse: item.Reverse}
+		if err := tx.Create(again).Error; err != nil {
+			tx.Rollback()
+			appErr := apperrors.AnswerQuizItemErr.AppendMessage(err)
+			rq.log.Error(appErr)
+			return appErr
+		}
+	}
+
+	if op != nil {
+		if err := applyWordOperation(tx, answer.UserID, op, schedule); err != nil {
+			tx.Rollback()
+			appErr := apperrors.AnswerQuizItemErr.AppendMessage(err)
+			rq.log.Error(appErr)
+			return appErr
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		appErr := apperrors.AnswerQuizItemErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+func (rq *repoQuiz) CountItems(ctx context.Context, sessionID *uuid.UUID) (*ItemCounts, error) {
+	counts := &ItemCounts{}
+	err := rq.db.WithContext(ctx).Model(&models.QuizItem{}).
+		Select("COUNT(*) AS items, COUNT(*) FILTER (WHERE answered_at IS NULL) AS remaining").
+		Where("session_id = ?", sessionID).
+		Scan(counts).Error
+	if err != nil {
+		appErr := apperrors.GetQuizItemErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return counts, nil
+}
+
+// GetAcceptedAnswers returns the answers of every word of the pair with the same prompt, so the synonyms are accepted too.
+func (rq *repoQuiz) GetAcceptedAnswers(ctx context.Context, word *models.Word, reverse bool) ([]string, error) {
+	promptColumn, answerColumn, prompt := "russian", "english", word.Russian
+	if reverse {
+		promptColumn, answerColumn, prompt = "english", "russian", word.English
+	}
+
+	var answers []string
+	err := rq.db.WithContext(ctx).Model(&models.Word{}).
+		Distinct(answerColumn).
+		Where("language_from = ? AND language_to = ? AND "+promptColumn+" = ?", word.LanguageFrom, word.LanguageTo, prompt).
+		Pluck(answerColumn, &answers).Error
+	if err != nil {
+		appErr := apperrors.GetAcceptedAnswersErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return answers, nil
+}
+
+// GetDistractors picks wrong answers for a multiple choice prompt, the words of the same theme and part of speech first,
+// then of the same part of speech, then any word of the pair. The seed keeps the pick the same for a prompt asked twice.
+func (rq *repoQuiz) GetDistractors(ctx context.Context, word *models.Word, reverse bool, seed string, limit int) ([]string, error) {
+	promptColumn, answerColumn, prompt, answer := "russian", "english", word.Russian, word.English
+	if reverse {
+		promptColumn, answerColumn, prompt, answer = "english", "russian", word.English, word.Russian
+	}
+
+	filters := []map[string]interface{}{{"parts_of_speech": word.PartsOfSpeech}, {}}
+	if word.Theme != "" {
+		filters = append([]map[string]interface{}{{"theme": word.Theme, "parts_of_speech": word.PartsOfSpeech}}, filters...)
+	}
+
+	seen := map[string]bool{answer: true}
+	distractors := []string{}
+	for _, filter := range filters {
+		query := rq.db.WithContext(ctx).Model(&models.Word{}).
+			Where("language_from = ? AND language_to = ?", word.LanguageFrom, word.LanguageTo).
+			Where(promptColumn+" <> ? AND "+answerColumn+" <> ?", prompt, answer)
+		if len(filter) != 0 {
+			query = query.Where(filter)
+		}
+
+		var answers []string
+		err := query.
+			Order(clause.OrderBy{Expression: clause.Expr{SQL: "md5(words.id || ?)", Vars: []interface{}{seed}}}).
+			Limit(limit*2).
+			Pluck(answerColumn, &answers).Error
+		if err != nil {
+			appErr := apperrors.GetDistractorsErr.AppendMessage(err)
+			rq.log.Error(appErr)
+			return nil, appErr
+		}
+
+		for _, distractor := range answers {
+			if seen[distractor] {
+				continue
+			}
+
+			seen[distractor] = true
+			distractors = append(distractors, distractor)
+			if len(distractors) == limit {
+				return distractors, nil
+			}
+		}
+	}
+
+	return distractors, nil
+}
+
+func (rq *repoQuiz) CountAnswers(ctx context.Context, sessionID *uuid.UUID) (*AnswerCounts, error) {
+	counts := &AnswerCounts{}
+	err := rq.db.WithContext(ctx).Model(&models.QuizAnswer{}).
+		Select("COUNT(*) AS answers, COUNT(*) FILTER (WHERE correct) AS correct, COUNT(*) FILTER (WHERE typo) AS typos").
+		Where("session_id = ?", sessionID).
+		Scan(counts).Error
+	if err != nil {
+		appErr := apperrors.CountQuizAnswersErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return counts, nil
+}
+
+// GetAnswerDays returns the days with at least one answer, the latest first.
+func (rq *repoQuiz) GetAnswerDays(ctx context.Context, userID *uuid.UUID) ([]time.Time, error) {
+	var days []time.Time
+	err := rq.db.WithContext(ctx).Model(&models.QuizAnswer{}).
+		Distinct("DATE(answered_at) AS day").
+		Where("user_id = ?", userID).
+		Order("day DESC").
+		Pluck("day", &days).Error
+	if err != nil {
+		appErr := apperrors.GetStatsErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return days, nil
+}
+
+func (rq *repoQuiz) GetDailyStats(ctx context.Context, userID *uuid.UUID, since time.Time) ([]*DailyStats, error) {
+	var stats []*DailyStats
+	err := rq.db.WithContext(ctx).Model(&models.QuizAnswer{}).
+		Select("DATE(answered_at) AS day, COUNT(*) AS answers, COUNT(*) FILTER (WHERE correct) AS correct").
+		Where("user_id = ? AND answered_at >= ?", userID, since).
+		Group("day").
+		Order("day").
+		Scan(&stats).Error
+	if err != nil {
+		appErr := apperrors.GetStatsErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return stats, nil
+}
+
+// GetLearnedPerWeek dates a learned word by the last update of its progress.
+func (rq *repoQuiz) GetLearnedPerWeek(ctx context.Context, userID *uuid.UUID, since time.Time) ([]*WeeklyStats, error) {
+	var stats []*WeeklyStats
+	err := rq.db.WithContext(ctx).Model(&models.Progress{}).
+		Select("DATE_TRUNC('week', updated_at) AS week, COUNT(*) AS learned").
+		Where("user_id = ? AND learned AND updated_at >= ?", userID, since).
+		Group("week").
+		Order("week").
+		Scan(&stats).Error
+	if err != nil {
+		appErr := apperrors.GetStatsErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return stats, nil
+}
+
+// GetHardestWords orders the words answered wrong at least once by their share of wrong answers.
+func (rq *repoQuiz) GetHardestWords(ctx context.Context, userID *uuid.UUID, limit int) ([]*WordStats, error) {
+	var stats []*WordStats
+	err := rq.db.WithContext(ctx).Model(&models.QuizAnswer{}).
+		Select("words.id AS word_id, words.english, words.russian, COUNT(*) AS answers, COUNT(*) FILTER (WHERE NOT quiz_answers.correct) AS wrong").
+		Joins("JOIN words ON words.id = quiz_answers.word_id").
+		Where("quiz_answers.user_id = ?", userID).
+		Group("words.id, words.english, words.russian").
+		Having("COUNT(*) FILTER (WHERE NOT quiz_answers.correct) > 0").
+		Order("COUNT(*) FILTER (WHERE NOT quiz_answers.correct)::float / COUNT(*) DESC, wrong DESC").
+		Limit(limit).
+		Scan(&stats).Error
+	if err != nil {
+		appErr := apperrors.GetStatsErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return stats, nil
+}
+
+// GetThemeStats counts the words of every theme of the pair with the ones the user learned and answered.
+func (rq *repoQuiz) GetThemeStats(ctx context.Context, userID *uuid.UUID, from string, to string) ([]*ThemeStats, error) {
+	var stats []*ThemeStats
+	err := rq.db.WithContext(ctx).Model(&models.Word{}).
+		Select("words.theme, COUNT(DISTINCT words.id) AS words, "+
+			"COUNT(DISTINCT words.id) FILTER (WHERE progresses.learned) AS learned, "+
+			"COUNT(quiz_answers.id) AS answers, COUNT(quiz_answers.id) FILTER (WHERE quiz_answers.correct) AS correct").
+		Joins("LEFT "+progressJoin, userID).
+		Joins("LEFT JOIN quiz_answers ON quiz_answers.word_id = words.id AND quiz_answers.user_id = ? AND quiz_answers.deleted_at IS NULL", userID).
+		Where("words.language_from = ? AND words.language_to = ? AND words.theme <> ''", from, to).
+		Group("words.theme").
+		Order("words.theme").
+		Scan(&stats).Error
+	if err != nil {
+		appErr := apperrors.GetStatsErr.AppendMessage(err)
+		rq.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"math"
+	"server/internal/domain/models"
+	"sync"
+	"time"
+)
+
+// RateLimiter keeps a token bucket by key, Take spends a token or tells how long the caller has to wait for one.
+// A full bucket is the same as no bucket, so DeleteIdle drops them.
+type RateLimiter interface {
+	Take(ctx context.Context, key string, limit models.RateLimit) (time.Duration, error)
+	DeleteIdle(ctx context.Context) error
+}
+
+type bucket struct {
+	tokens   float64
+	burst    float64
+	perSec   float64
+	updateAt time.Time
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.updateAt).Seconds()*b.perSec)
+	b.updateAt = now
+}
+
+type memoryRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func NewMemoryRateLimiter() RateLimiter {
+	return &memoryRateLimiter{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Take returns 0 when the request may go on, otherwise the time until the bucket has a token again.
+// A limit without a rate doesn't limit.
+func (ml *memoryRateLimiter) Take(ctx context.Context, key string, limit models.RateLimit) (time.Duration, error) {
+	if limit.PerMinute <= 0 || limit.Burst <= 0 {
+		return 0, nil
+	}
+
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	now := ml.now()
+	b, ok := ml.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updateAt: now}
+		ml.buckets[key] = b
+	}
+
+	b.burst = float64(limit.Burst)
+	b.perSec = float64(limit.PerMinute) / 60
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, nil
+	}
+
+	return time.Duration((1 - b.tokens) / b.perSec * float64(time.Second)), nil
+}
+
+func (ml *memoryRateLimiter) DeleteIdle(ctx context.Context) error {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	now := ml.now()
+	for key, b := range ml.buckets {
+		if b.refill(now); b.tokens >= b.burst {
+			delete(ml.buckets, key)
+		}
+	}
+
+	return nil
+}
+
+// LoginLockout counts the failed logins by key. Every failure from the threshold on locks the key,
+// the lockout doubles with every one of them up to the max. A success forgets the failures.
+type LoginLockout interface {
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	Fail(ctx context.Context, key string) (time.Duration, error)
+	Reset(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context) error
+}
+
+type lockout struct {
+	failures    int
+	lockedUntil time.Time
+	failedAt    time.Time
+}
+
+type memoryLoginLockout struct {
+	mu        sync.Mutex
+	threshold int
+	base      time.Duration
+	max       time.Duration
+	lockouts  map[string]*lockout
+	now       func() time.Time
+}
+
+func NewMemoryLoginLockout(threshold int, base time.Duration, max time.Duration) LoginLockout {
+	return &memoryLoginLockout{threshold: threshold, base: base, max: max, lockouts: make(map[string]*lockout), now: time.Now}
+}
+
+func (ml *memoryLoginLockout) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	l, ok := ml.lockouts[key]
+	if !ok {
+		return 0, nil
+	}
+
+	if lockedFor := l.lockedUntil.Sub(ml.now()); lockedFor > 0 {
+		return lockedFor, nil
+	}
+
+	return 0, nil
+}
+
+// Fail counts a failure and returns the lockout it has started, 0 below the threshold.
+func (ml *memoryLoginLockout) Fail(ctx context.Context, key string) (time.Duration, error) {
+	if ml.threshold <= 0 {
+		return 0, nil
+	}
+
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	now := ml.now()
+	l, ok := ml.lockouts[key]
+	if !ok || now.Sub(l.failedAt) > ml.max && now.After(l.lockedUntil) {
+		l = &lockout{}
+		ml.lockouts[key] = l
+	}
+
+	l.failures++
+	l.failedAt = now
+	if l.failures < ml.threshold {
+		return 0, nil
+	}
+
+	lockedFor := ml.base
+	for i := ml.threshold; i < l.failures && lockedFor < ml.max; i++ {
+		lockedFor *= 2
+	}
+
+	if lockedFor > ml.max {
+		lockedFor = ml.max
+	}
+
+	l.lockedUntil = now.Add(lockedFor)
+	return lockedFor, nil
+}
+
+func (ml *memoryLoginLockout) Reset(ctx context.Context, key string) error {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	delete(ml.lockouts, key)
+	return nil
+}
+
+// DeleteExpired drops the keys which haven't failed for the max lockout and aren't locked.
+func (ml *memoryLoginLockout) DeleteExpired(ctx context.Context) error {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	now := ml.now()
+	for key, l := range ml.lockouts {
+		if now.Sub(l.failedAt) > ml.max && now.After(l.lockedUntil) {
+			delete(ml.lockouts, key)
+		}
+	}
+
+	return nil
+}
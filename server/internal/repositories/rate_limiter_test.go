@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"context"
+	"server/internal/domain/models"
+	"testing"
+	"time"
+)
+
+// clock is a time the tests move by hand.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func TestMemoryRateLimiterTake(t *testing.T) {
+	type take struct {
+		after     time.Duration
+		key       string
+		wantRetry time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		limit models.RateLimit
+		steps []take
+	}{
+		{
+			name:  "burst then refill",
+			limit: models.RateLimit{PerMinute: 60, Burst: 2},
+			steps: []take{
+				{after: 0, key: "ip:1", wantRetry: 0},
+				{after: 0, key: "ip:1", wantRetry: 0},
+				{after: 0, key: "ip:1", wantRetry: time.Second},
+				{after: 500 * time.Millisecond, key: "ip:1", wantRetry: 500 * time.Millisecond},
+				{after: 500 * time.Millisecond, key: "ip:1", wantRetry: 0},
+				{after: 0, key: "ip:1", wantRetry: time.Second},
+			},
+		},
+		{
+			name:  "keys have their own buckets",
+			limit: models.RateLimit{PerMinute: 60, Burst: 1},
+			steps: []take{
+				{after: 0, key: "ip:1", wantRetry: 0},
+				{after: 0, key: "ip:1", wantRetry: time.Second},
+				{after: 0, key: "ip:2", wantRetry: 0},
+			},
+		},
+		{
+			name:  "a limit without a rate doesn't limit",
+			limit: models.RateLimit{},
+			steps: []take{
+				{after: 0, key: "ip:1", wantRetry: 0},
+				{after: 0, key: "ip:1", wantRetry: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &clock{now: time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)}
+			limiter := &memoryRateLimiter{buckets: make(map[string]*bucket), now: c.Now}
+			for i, step := range tt.steps {
+				c.now = c.now.Add(step.after)
+				retry, err := limiter.Take(context.Background(), step.key, tt.limit)
+				if err != nil {
+					t.Fatalf("step %v: %v", i, err)
+				}
+
+				if retry != step.wantRetry {
+					t.Errorf("step %v: Take = %v, want %v", i, retry, step.wantRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimiterDeleteIdle(t *testing.T) {
+	c := &clock{now: time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := &memoryRateLimiter{buckets: make(map[string]*bucket), now: c.Now}
+	limit := models.RateLimit{PerMinute: 60, Burst: 2}
+	ctx := context.Background()
+	limiter.Take(ctx, "ip:1", limit)
+	limiter.Take(ctx, "ip:1", limit)
+
+	c.now = c.now.Add(time.Second)
+	limiter.DeleteIdle(ctx)
+	if _, ok := limiter.buckets["ip:1"]; !ok {
+		t.Fatal("DeleteIdle has dropped a bucket which isn't full")
+	}
+
+	c.now = c.now.Add(time.Second)
+	limiter.DeleteIdle(ctx)
+	if _, ok := limiter.buckets["ip:1"]; ok {
+		t.Fatal("DeleteIdle has kept a full bucket")
+	}
+}
+
+func TestMemoryLoginLockout(t *testing.T) {
+	type step struct {
+		after      time.Duration
+		fail       bool
+		reset      bool
+		wantLocked time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "lockout doubles up to the max",
+			steps: []step{
+				{fail: true, wantLocked: 0},
+				{fail: true, wantLocked: 0},
+				{fail: true, wantLocked: time.Second},
+				{after: time.Second, fail: true, wantLocked: 2 * time.Second},
+				{after: 2 * time.Second, fail: true, wantLocked: 4 * time.Second},
+				{after: 4 * time.Second, fail: true, wantLocked: 5 * time.Second},
+				{after: 5 * time.Second, fail: true, wantLocked: 5 * time.Second},
+			},
+		},
+		{
+			name: "locked for the rest of the lockout",
+			steps: []step{
+				{fail: true},
+				{fail: true},
+				{fail: true, wantLocked: time.Second},
+				{after: 400 * time.Millisecond, wantLocked: 600 * time.Millisecond},
+				{after: 600 * time.Millisecond, wantLocked: 0},
+			},
+		},
+		{
+			name: "a success forgets the failures",
+			steps: []step{
+				{fail: true},
+				{fail: true},
+				{reset: true},
+				{fail: true, wantLocked: 0},
+				{fail: true, wantLocked: 0},
+				{fail: true, wantLocked: time.Second},
+			},
+		},
+		{
+			name: "old failures are forgotten",
+			steps: []step{
+				{fail: true},
+				{fail: true},
+				{after: 6 * time.Second, fail: true, wantLocked: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &clock{now: time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)}
+			lockout := NewMemoryLoginLockout(3, time.Second, 5*time.Second).(*memoryLoginLockout)
+			lockout.now = c.Now
+			ctx := context.Background()
+			for i, step := range tt.steps {
+				c.now = c.now.Add(step.after)
+				var locked time.Duration
+				var err error
+				switch {
+				case step.fail:
+					locked, err = lockout.Fail(ctx, "email:a@b.io")
+				case step.reset:
+					err = lockout.Reset(ctx, "email:a@b.io")
+				default:
+					locked, err = lockout.LockedFor(ctx, "email:a@b.io")
+				}
+
+				if err != nil {
+					t.Fatalf("step %v: %v", i, err)
+				}
+
+				if locked != step.wantLocked {
+					t.Errorf("step %v: locked for %v, want %v", i, locked, step.wantLocked)
+				}
+			}
+		})
+	}
+}
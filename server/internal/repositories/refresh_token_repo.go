@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"context"
+	"server/internal/apperrors"
+	"server/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type RepoRefreshTokens interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldToken *models.RefreshToken, newToken *models.RefreshToken) error
+	RevokeRefreshToken(ctx context.Context, tokenHash string) error
+	RevokeUserDeviceTokens(ctx context.Context, userID *uuid.UUID, device string) error
+}
+
+type repoRefreshTokens struct {
+	db  *gorm.DB
+	log *logrus.Logger
+}
+
+func NewRepoRefreshTokens(db *gorm.DB, log *logrus.Logger) RepoRefreshTokens {
+	return &repoRefreshTokens{db: db, log: log}
+}
+
+func (rt *repoRefreshTokens) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	if token == nil {
+		appErr := apperrors.CreateRefreshTokenErr.AppendMessage("token is nil")
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	err := rt.db.WithContext(ctx).Create(token).Error
+	if err != nil {
+		appErr := apperrors.CreateRefreshTokenErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+func (rt *repoRefreshTokens) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+	result := rt.db.WithContext(ctx).Where("token_hash = ?", tokenHash).Limit(1).Find(token)
+	if result.Error != nil {
+		appErr := apperrors.GetRefreshTokenErr.AppendMessage(result.Error)
+		rt.log.Error(appErr)
+		return nil, appErr
+	}
+
+	if result.RowsAffected == 0 {
+		appErr := apperrors.GetRefreshTokenErr.AppendMessage("refresh token not found")
+		rt.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return token, nil
+}
+
+// RotateRefreshToken revokes the old token and stores the new one in one transaction.
+// It fails when the old token has been revoked concurrently.
+func (rt *repoRefreshTokens) RotateRefreshToken(ctx context.Context, oldToken *models.RefreshToken, newToken *models.RefreshToken) error {
+	tx := rt.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		appErr := apperrors.RotateRefreshTokenErr.AppendMessage(tx.Error)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	result := tx.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", oldToken.ID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		tx.Rollback()
+		appErr := apperrors.RotateRefreshTokenErr.AppendMessage(result.Error)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		appErr := apperrors.RotateRefreshTokenErr.AppendMessage("refresh token has already been used")
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	if err := tx.Create(newToken).Error; err != nil {
+		tx.Rollback()
+		appErr := apperrors.RotateRefreshTokenErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		appErr := apperrors.RotateRefreshTokenErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+func (rt *repoRefreshTokens) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
+	err := rt.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("token_hash = ? AND revoked_at IS NULL", tokenHash).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		appErr := apperrors.RevokeRefreshTokenErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+func (rt *repoRefreshTokens) RevokeUserDeviceTokens(ctx context.Context, userID *uuid.UUID, device string) error {
+	err := rt.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND device = ? AND revoked_at IS NULL", userID, device).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		appErr := apperrors.RevokeRefreshTokenErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
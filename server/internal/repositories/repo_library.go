@@ -5,17 +5,23 @@ import (
 	"server/internal/apperrors"
 	"server/internal/domain/models"
 
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RepoLibrary interface {
 	GetAllWords() ([]*models.Library, error)
-	GetTranslationRus(word string) ([]*models.Library, error)
-	GetTranslationRusLike(word string) ([]*models.Library, error)
-	GetTranslationEngl(word string) ([]*models.Library, error)
-	GetTranslationEnglLike(word string) ([]*models.Library, error)
 	InsertWordsLibrary(ctx context.Context, library []*models.Library) error
+	CountWords(ctx context.Context) (int64, error)
+	GetWordByID(ctx context.Context, id int) (*models.Library, error)
+	CreateWord(ctx context.Context, word *models.Library) error
+	UpdateWord(ctx context.Context, word *models.Library) error
+	DeleteWord(ctx context.Context, id int) error
+	ImportWords(ctx context.Context, library []*models.Library) (int, error)
+	GetRandomPhrases(ctx context.Context, theme string, limit int) ([]*models.Phrase, error)
+	GetPhraseByID(ctx context.Context, id int) (*models.Phrase, error)
 }
 
 type repoLibrary struct {
@@ -39,89 +45,376 @@ func (rt *repoLibrary) GetAllWords() ([]*models.Library, error) {
 	return words, nil
 }
 
-func (rt *repoLibrary) GetTranslationRus(word string) ([]*models.Library, error) {
-	var words []*models.Library
-	err := rt.db.Where("russian = ?", word).Find(&words).Error
+func (rt *repoLibrary) InsertWordsLibrary(ctx context.Context, library []*models.Library) error {
+	for _, word := range library {
+		if word == nil {
+			appErr := apperrors.InsertWordsLibraryErr.AppendMessage("lib == nil")
+			rt.log.Error(appErr)
+			return appErr
+		}
+
+		tx := rt.db.WithContext(ctx)
+		if tx.Error != nil {
+			appErr := apperrors.InsertWordsLibraryErr.AppendMessage(tx.Error)
+			rt.log.Error(appErr)
+			return appErr
+		}
+
+		result := tx.Create(word)
+		if result.Error != nil {
+			appErr := apperrors.InsertWordsLibraryErr.AppendMessage(result.Error)
+			rt.log.Error(appErr)
+			return appErr
+		}
+
+		if result.RowsAffected == 0 {
+			appErr := apperrors.InsertWordsLibraryErr.AppendMessage("no rows affected")
+			rt.log.Error(appErr)
+			return appErr
+		}
+
+		createdLib := &models.Library{}
+		if err := tx.First(createdLib, "id = ?", word.ID).Error; err != nil {
+			appErr := apperrors.InsertWordsLibraryErr.AppendMessage(err)
+			rt.log.Error(appErr)
+			return appErr
+		}
+	}
+
+	return nil
+}
+
+func (rt *repoLibrary) CountWords(ctx context.Context) (int64, error) {
+	var count int64
+	err := rt.db.WithContext(ctx).Unscoped().Model(&models.Library{}).Count(&count).Error
 	if err != nil {
-		appErr := apperrors.GetTranslationRusErr.AppendMessage(err)
+		appErr := apperrors.CountWordsLibErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return 0, appErr
+	}
+
+	return count, nil
+}
+
+func (rt *repoLibrary) GetWordByID(ctx context.Context, id int) (*models.Library, error) {
+	word := &models.Library{}
+	result := rt.db.WithContext(ctx).Preload("Phrases").Where("id = ?", id).Limit(1).Find(word)
+	if result.Error != nil {
+		appErr := apperrors.GetWordByIDLibErr.AppendMessage(result.Error)
 		rt.log.Error(appErr)
 		return nil, appErr
 	}
 
-	return words, nil
+	if result.RowsAffected == 0 {
+		appErr := apperrors.GetWordByIDLibErr.AppendMessage(&apperrors.WordNotFoundErr)
+		rt.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return word, nil
 }
 
-func (rt *repoLibrary) GetTranslationRusLike(word string) ([]*models.Library, error) {
-	var words []*models.Library
-	err := rt.db.Where("russian LIKE ?", "%"+word+"%").Find(&words).Error
+// GetRandomPhrases picks phrases with both texts, a theme narrows them down to the phrases of the entries of that theme.
+func (rt *repoLibrary) GetRandomPhrases(ctx context.Context, theme string, limit int) ([]*models.Phrase, error) {
+	query := rt.db.WithContext(ctx).Model(&models.Phrase{}).
+		Where("phrases.english <> '' AND phrases.russian <> ''")
+	if theme != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM library_phrases JOIN libraries ON libraries.id = library_phrases.library_id "+
+			"WHERE library_phrases.phrase_id = phrases.id AND libraries.deleted_at IS NULL AND libraries.theme = ?)", theme)
+	}
+
+	var phrases []*models.Phrase
+	err := query.Order("random()").Limit(limit).Find(&phrases).Error
 	if err != nil {
-		appErr := apperrors.GetTranslationRusLikeErr.AppendMessage(err)
+		appErr := apperrors.GetPhrasesErr.AppendMessage(err)
 		rt.log.Error(appErr)
 		return nil, appErr
 	}
 
-	return words, nil
+	return phrases, nil
 }
 
-func (rt *repoLibrary) GetTranslationEngl(word string) ([]*models.Library, error) {
-	var words []*models.Library
-	err := rt.db.Where("english = ?", word).Find(&words).Error
-	if err != nil {
-		appErr := apperrors.GetTranslationEnglErr.AppendMessage(err)
+func (rt *repoLibrary) GetPhraseByID(ctx context.Context, id int) (*models.Phrase, error) {
+	phrase := &models.Phrase{}
+	result := rt.db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(phrase)
+	if result.Error != nil {
+		appErr := apperrors.GetPhraseByIDErr.AppendMessage(result.Error)
 		rt.log.Error(appErr)
 		return nil, appErr
 	}
 
-	return words, nil
+	if result.RowsAffected == 0 {
+		appErr := apperrors.GetPhraseByIDErr.AppendMessage(&apperrors.PhraseNotFoundErr)
+		rt.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return phrase, nil
 }
 
-func (rt *repoLibrary) GetTranslationEnglLike(word string) ([]*models.Library, error) {
-	var words []*models.Library
-	err := rt.db.Where("english LIKE ?", "%"+word+"%").Find(&words).Error
+func (rt *repoLibrary) CreateWord(ctx context.Context, word *models.Library) error {
+	if word == nil {
+		appErr := apperrors.CreateWordLibErr.AppendMessage("word is nil")
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	err := rt.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(word).Error; err != nil {
+			return err
+		}
+
+		if err := syncLibraryTranslations(tx, word); err != nil {
+			return err
+		}
+
+		return syncLibraryWord(tx, word)
+	})
 	if err != nil {
-		appErr := apperrors.GetTranslationEnglLikeErr.AppendMessage(err)
+		appErr := apperrors.CreateWordLibErr.AppendMessage(err)
 		rt.log.Error(appErr)
-		return nil, appErr
+		return appErr
 	}
 
-	return words, nil
+	return nil
 }
 
-func (rt *repoLibrary) InsertWordsLibrary(ctx context.Context, library []*models.Library) error {
+// UpdateWord overwrites the entry fields and replaces its phrases.
+func (rt *repoLibrary) UpdateWord(ctx context.Context, word *models.Library) error {
+	tx := rt.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		appErr := apperrors.UpdateWordErr.AppendMessage(tx.Error)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	result := tx.Model(&models.Library{}).Where("id = ?", word.ID).Updates(map[string]interface{}{
+		"english":         word.English,
+		"russian":         word.Russian,
+		"theme":           word.Theme,
+		"parts_of_speech": word.PartsOfSpeech,
+		"exceptions":      word.Exceptions,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		appErr := apperrors.UpdateWordErr.AppendMessage(result.Error)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		appErr := apperrors.UpdateWordErr.AppendMessage(&apperrors.WordNotFoundErr)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	if err := tx.Model(word).Association("Phrases").Replace(word.Phrases); err != nil {
+		tx.Rollback()
+		appErr := apperrors.UpdateWordErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	if err := syncLibraryTranslations(tx, word); err != nil {
+		tx.Rollback()
+		appErr := apperrors.UpdateWordErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	if err := syncLibraryWord(tx, word); err != nil {
+		tx.Rollback()
+		appErr := apperrors.UpdateWordErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		appErr := apperrors.UpdateWordErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+func (rt *repoLibrary) DeleteWord(ctx context.Context, id int) error {
+	tx := rt.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		appErr := apperrors.DeleteWordLibErr.AppendMessage(tx.Error)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	word := &models.Library{ID: id}
+	if err := tx.Model(word).Association("Phrases").Clear(); err != nil {
+		tx.Rollback()
+		appErr := apperrors.DeleteWordLibErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	result := tx.Delete(word)
+	if result.Error != nil {
+		tx.Rollback()
+		appErr := apperrors.DeleteWordLibErr.AppendMessage(result.Error)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		appErr := apperrors.DeleteWordLibErr.AppendMessage(&apperrors.WordNotFoundErr)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	if err := deleteLibraryTranslations(tx, id); err != nil {
+		tx.Rollback()
+		appErr := apperrors.DeleteWordLibErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	if err := deleteLibraryWord(tx, id); err != nil {
+		tx.Rollback()
+		appErr := apperrors.DeleteWordLibErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		appErr := apperrors.DeleteWordLibErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+// ImportWords upserts the entries by ID in one transaction. Entries without ID are created.
+func (rt *repoLibrary) ImportWords(ctx context.Context, library []*models.Library) (int, error) {
+	tx := rt.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		appErr := apperrors.ImportWordsLibErr.AppendMessage(tx.Error)
+		rt.log.Error(appErr)
+		return 0, appErr
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	imported := 0
 	for _, word := range library {
 		if word == nil {
-			appErr := apperrors.InsertWordsLibraryErr.AppendMessage("lib == nil")
-			rt.log.Error(appErr)
-			return appErr
+			continue
 		}
 
-		tx := rt.db.WithContext(ctx)
-		if tx.Error != nil {
-			appErr := apperrors.InsertWordsLibraryErr.AppendMessage(tx.Error)
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"updated_at", "deleted_at", "english", "russian", "theme", "parts_of_speech", "exceptions"}),
+		}).Omit("Phrases").Create(word).Error
+		if err != nil {
+			tx.Rollback()
+			appErr := apperrors.ImportWordsLibErr.AppendMessage(err)
 			rt.log.Error(appErr)
-			return appErr
+			return 0, appErr
 		}
 
-		result := tx.Create(word)
-		if result.Error != nil {
-			appErr := apperrors.InsertWordsLibraryErr.AppendMessage(result.Error)
-			rt.log.Error(appErr)
-			return appErr
+		if word.Phrases != nil {
+			if err := tx.Model(word).Association("Phrases").Replace(word.Phrases); err != nil {
+				tx.Rollback()
+				appErr := apperrors.ImportWordsLibErr.AppendMessage(err)
+				rt.log.Error(appErr)
+				return 0, appErr
+			}
 		}
 
-		if result.RowsAffected == 0 {
-			appErr := apperrors.InsertWordsLibraryErr.AppendMessage("no rows affected")
+		if err := syncLibraryTranslations(tx, word); err != nil {
+			tx.Rollback()
+			appErr := apperrors.ImportWordsLibErr.AppendMessage(err)
 			rt.log.Error(appErr)
-			return appErr
+			return 0, appErr
 		}
 
-		createdLib := &models.Library{}
-		if err := tx.First(createdLib, "id = ?", word.ID).Error; err != nil {
-			appErr := apperrors.InsertWordsLibraryErr.AppendMessage(err)
+		if err := syncLibraryWord(tx, word); err != nil {
+			tx.Rollback()
+			appErr := apperrors.ImportWordsLibErr.AppendMessage(err)
 			rt.log.Error(appErr)
-			return appErr
+			return 0, appErr
 		}
+
+		imported++
 	}
 
-	return nil
+	// Explicit IDs from the file don't move the serial sequence forward.
+	err := tx.Exec("SELECT setval(pg_get_serial_sequence('libraries', 'id'), COALESCE(MAX(id), 1)) FROM libraries").Error
+	if err != nil {
+		tx.Rollback()
+		appErr := apperrors.ImportWordsLibErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return 0, appErr
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		appErr := apperrors.ImportWordsLibErr.AppendMessage(err)
+		rt.log.Error(appErr)
+		return 0, appErr
+	}
+
+	return imported, nil
+}
+
+// syncLibraryWord keeps the shared ru -> en word of the Library entry in step with it, the progress of users stays on the word.
+func syncLibraryWord(tx *gorm.DB, word *models.Library) error {
+	if word.DeletedAt.Valid {
+		return deleteLibraryWord(tx, word.ID)
+	}
+
+	result := tx.Unscoped().Model(&models.Word{}).Where("library_id = ?", word.ID).Updates(map[string]interface{}{
+		"english":         word.English,
+		"russian":         word.Russian,
+		"theme":           word.Theme,
+		"parts_of_speech": word.PartsOfSpeech,
+		"deleted_at":      nil,
+	})
+	if result.Error != nil || result.RowsAffected != 0 {
+		return result.Error
+	}
+
+	id := uuid.New()
+	libraryID := word.ID
+	return tx.Omit("Library").Create(&models.Word{
+		ID:            &id,
+		English:       word.English,
+		Russian:       word.Russian,
+		Theme:         word.Theme,
+		PartsOfSpeech: word.PartsOfSpeech,
+		LanguageFrom:  models.LanguageRussian,
+		LanguageTo:    models.LanguageEnglish,
+		LibraryID:     &libraryID,
+	}).Error
+}
+
+func deleteLibraryWord(tx *gorm.DB, libraryID int) error {
+	return tx.Where("library_id = ?", libraryID).Delete(&models.Word{}).Error
 }
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"server/internal/apperrors"
+	"server/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type RepoReviews interface {
+	GetDueWordsByIDAndLimit(ctx context.Context, userID *uuid.UUID, now time.Time, limit int) ([]*models.Word, error)
+	GetReview(ctx context.Context, userID *uuid.UUID, wordID *uuid.UUID) (*models.Review, error)
+	SaveReview(ctx context.Context, review *models.Review) error
+}
+
+type repoReviews struct {
+	db  *gorm.DB
+	log *logrus.Logger
+}
+
+func NewRepoReviews(db *gorm.DB, log *logrus.Logger) RepoReviews {
+	return &repoReviews{db: db, log: log}
+}
+
+// GetDueWordsByIDAndLimit returns learned words whose next review is due.
+// Learned words without a review state yet are treated as due first.
+func (rr *repoReviews) GetDueWordsByIDAndLimit(ctx context.Context, userID *uuid.UUID, now time.Time, limit int) ([]*models.Word, error) {
+	var words []*models.Word
+	err := rr.db.WithContext(ctx).
+		Joins("JOIN progresses ON progresses.word_id = words.id AND progresses.user_id = ? AND progresses.learned AND progresses.deleted_at IS NULL", userID).
+		Joins("LEFT JOIN reviews ON reviews.word_id = words.id AND reviews.user_id = ? AND reviews.deleted_at IS NULL", userID).
+		Where("reviews.id IS NULL OR reviews.next_review_at <= ?", now).
+		Order("reviews.next_review_at ASC NULLS FIRST").
+		Limit(limit).
+		Find(&words).Error
+	if err != nil {
+		appErr := apperrors.GetDueWordsErr.AppendMessage(err)
+		rr.log.Error(appErr)
+		return nil, appErr
+	}
+
+	return words, nil
+}
+
+// GetReview returns nil without error when the word has never been reviewed.
+func (rr *repoReviews) GetReview(ctx context.Context, userID *uuid.UUID, wordID *uuid.UUID) (*models.Review, error) {
+	var reviews []*models.Review
+	err := rr.db.WithContext(ctx).Where("user_id = ? AND word_id = ?", userID, wordID).Limit(1).Find(&reviews).Error
+	if err != nil {
+		appErr := apperrors.GetReviewErr.AppendMessage(err)
+		rr.log.Error(appErr)
+		return nil, appErr
+	}
+
+	if len(reviews) == 0 {
+		return nil, nil
+	}
+
+	return reviews[0], nil
+}
+
+func (rr *repoReviews) SaveReview(ctx context.Context, review *models.Review) error {
+	if review == nil {
+		appErr := apperrors.SaveReviewErr.AppendMessage("review is nil")
+		rr.log.Error(appErr)
+		return appErr
+	}
+
+	err := rr.db.WithContext(ctx).Save(review).Error
+	if err != nil {
+		appErr := apperrors.SaveReviewErr.AppendMessage(err)
+		rr.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
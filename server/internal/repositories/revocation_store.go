@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"server/internal/apperrors"
+	"server/internal/domain/models"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevocationStore keeps revoked access tokens by their jti claim.
+// Entries are useless once the token has expired, so they are dropped after expiresAt.
+type RevocationStore interface {
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+type memoryRevocationStore struct {
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+}
+
+func NewMemoryRevocationStore() RevocationStore {
+	return &memoryRevocationStore{tokens: make(map[string]time.Time)}
+}
+
+func (ms *memoryRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.tokens[jti] = expiresAt
+	return nil
+}
+
+func (ms *memoryRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	expiresAt, ok := ms.tokens[jti]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+func (ms *memoryRevocationStore) DeleteExpired(ctx context.Context) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	now := time.Now()
+	for jti, expiresAt := range ms.tokens {
+		if !now.Before(expiresAt) {
+			delete(ms.tokens, jti)
+		}
+	}
+
+	return nil
+}
+
+type pgRevocationStore struct {
+	db  *gorm.DB
+	log *logrus.Logger
+}
+
+func NewPgRevocationStore(db *gorm.DB, log *logrus.Logger) RevocationStore {
+	return &pgRevocationStore{db: db, log: log}
+}
+
+func (ps *pgRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	revoked := &models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}
+	err := ps.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(revoked).Error
+	if err != nil {
+		appErr := apperrors.RevokeTokenErr.AppendMessage(err)
+		ps.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+func (ps *pgRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	err := ps.db.WithContext(ctx).Model(&models.RevokedToken{}).
+		Where("jti = ? AND expires_at > ?", jti, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		appErr := apperrors.IsTokenRevokedErr.AppendMessage(err)
+		ps.log.Error(appErr)
+		return false, appErr
+	}
+
+	return count > 0, nil
+}
+
+func (ps *pgRevocationStore) DeleteExpired(ctx context.Context) error {
+	err := ps.db.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&models.RevokedToken{}).Error
+	if err != nil {
+		appErr := apperrors.DeleteExpiredTokensErr.AppendMessage(err)
+		ps.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
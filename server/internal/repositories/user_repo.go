@@ -2,24 +2,44 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"server/internal/apperrors"
 	"server/internal/domain/models"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// WordsFilter narrows a word list down, AfterCreatedAt and AfterID are the last word of the previous page.
+type WordsFilter struct {
+	From           string
+	To             string
+	Theme          string
+	PartsOfSpeech  string
+	Search         string
+	AfterCreatedAt time.Time
+	AfterID        *uuid.UUID
+	Limit          int
+}
+
 type RepoUsers interface {
 	UpdateUser(ctx context.Context, user *models.User) error
 	CreateUser(ctx context.Context, user *models.User) (string, error)
-	GetWordsByIDAndLimit(ctx context.Context, id *uuid.UUID, limit int) ([]*models.Word, error)
-	GetLearnByIDAndLimit(ctx context.Context, id *uuid.UUID, limit int) ([]*models.Word, error)
+	GetWordsByIDAndLimit(ctx context.Context, id *uuid.UUID, filter *WordsFilter) ([]*models.Word, int64, error)
+	GetLearnByIDAndLimit(ctx context.Context, id *uuid.UUID, filter *WordsFilter) ([]*models.Word, int64, error)
+	GetLearnedByIDAndLimit(ctx context.Context, id *uuid.UUID, filter *WordsFilter) ([]*models.Word, int64, error)
+	CreateWords(ctx context.Context, words []*models.Word) (int, error)
+	UpdateUserLanguagePair(ctx context.Context, id *uuid.UUID, from string, to string) error
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	GetUserById(ctx context.Context, id *uuid.UUID) (*models.User, error)
-	MoveWordToLearned(ctx context.Context, user *models.User, word *models.Word) error
+	UpdateUserRole(ctx context.Context, id *uuid.UUID, role string) error
+	GrantAdmins(ctx context.Context, emails []string) (int64, error)
 	AddWordToLearn(ctx context.Context, user *models.User, word *models.Word) error
 	DeleteLearnWordFromUserByWordID(ctx context.Context, user *models.User, word *models.Word) error
+	ApplyWordOperations(ctx context.Context, user *models.User, ops []*models.WordOperation, schedule func(review *models.Review)) error
 }
 
 type repoUsers struct {
@@ -55,62 +75,44 @@ func (usr *repoUsers) GetUserByEmail(ctx context.Context, email string) (*models
 	return user, nil
 }
 
-func (usr *repoUsers) MoveWordToLearned(ctx context.Context, user *models.User, word *models.Word) error {
-	tx := usr.db.Begin()
-	if tx.Error != nil {
-		appErr := apperrors.MoveWordToLearnedErr.AppendMessage(tx.Error)
+func (usr *repoUsers) AddWordToLearn(ctx context.Context, user *models.User, word *models.Word) error {
+	err := saveProgress(usr.db.WithContext(ctx), &models.Progress{UserID: user.ID, WordID: word.ID, InLearn: true}, "in_learn")
+	if err != nil {
+		appErr := apperrors.AddWordToLearnRepoErr.AppendMessage(err)
 		usr.log.Error(appErr)
 		return appErr
 	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
+	return nil
+}
 
-	association := tx.Model(user).Association("Words")
-	if association.Error != nil {
-		tx.Rollback()
-		appErr := apperrors.MoveWordToLearnedErr.AppendMessage(association.Error)
-		usr.log.Error(appErr)
-		return appErr
+// saveProgress creates the progress on the first interaction, later ones only update the column.
+func saveProgress(db *gorm.DB, progress *models.Progress, column string) error {
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "word_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{column, "updated_at"}),
+	}).Omit("Word").Create(progress).Error
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return apperrors.WordNotFoundErr.AppendMessage(err)
 	}
 
-	if err := association.Delete(word); err != nil {
-		tx.Rollback()
-		appErr := apperrors.MoveWordToLearnedErr.AppendMessage(err)
-		usr.log.Error(appErr)
-		return appErr
-	}
+	return err
+}
 
-	err := tx.Model(user).Association("Learned").Append(word)
-	if err != nil {
-		tx.Rollback()
-		appErr := apperrors.MoveWordToLearnedErr.AppendMessage(err)
-		usr.log.Error(appErr)
-		return appErr
+// scheduleReview reads the review of the word, a new one when the word has never been reviewed, and saves it graded.
+func scheduleReview(db *gorm.DB, userID *uuid.UUID, wordID *uuid.UUID, schedule func(review *models.Review)) error {
+	var reviews []*models.Review
+	if err := db.Where("user_id = ? AND word_id = ?", userID, wordID).Limit(1).Find(&reviews).Error; err != nil {
+		return err
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		tx.Rollback()
-		appErr := apperrors.MoveWordToLearnedErr.AppendMessage(err)
-		usr.log.Error(appErr)
-		return appErr
+	review := &models.Review{UserID: userID, WordID: wordID}
+	if len(reviews) > 0 {
+		review = reviews[0]
 	}
 
-	return nil
-}
-
-func (usr *repoUsers) AddWordToLearn(ctx context.Context, user *models.User, word *models.Word) error {
-	err := usr.db.Model(user).Association("Learn").Append(word)
-	if err != nil {
-		appErr := apperrors.AddWordToLearnRepoErr.AppendMessage(err)
-		usr.log.Error(appErr)
-		return appErr
-	}
-
-	return nil
+	schedule(review)
+	return db.Save(review).Error
 }
 
 func (usr *repoUsers) UpdateUser(ctx context.Context, user *models.User) error {
@@ -152,6 +154,12 @@ func (repo *repoUsers) CreateUser(ctx context.Context, user *models.User) (strin
 	}
 
 	result := tx.Create(user)
+	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+		appErr := apperrors.CreateUserErr.AppendMessage(apperrors.EmailTakenErr.AppendMessage(result.Error))
+		repo.log.Error(appErr)
+		return "", appErr
+	}
+
 	if result.Error != nil {
 		appErr := apperrors.CreateUserErr.AppendMessage(result.Error)
 		repo.log.Error(appErr)
@@ -174,48 +182,247 @@ func (repo *repoUsers) CreateUser(ctx context.Context, user *models.User) (strin
 	return createdUser.ID.String(), nil
 }
 
-func (usr *repoUsers) GetWordsByIDAndLimit(ctx context.Context, id *uuid.UUID, limit int) ([]*models.Word, error) {
-	var user *models.User
-	err := usr.db.Preload("Words", func(db *gorm.DB) *gorm.DB {
-		return db.Limit(limit)
-	}).Where("id = ?", id).Find(&user).Error
+func (usr *repoUsers) GetWordsByIDAndLimit(ctx context.Context, id *uuid.UUID, filter *WordsFilter) ([]*models.Word, int64, error) {
+	words, total, err := usr.findWordsPage(ctx, "LEFT "+progressJoin, "progresses.learned IS NOT TRUE", id, filter)
 	if err != nil {
 		appErr := apperrors.GetWordsByIDAndLimitErr.AppendMessage(err)
 		usr.log.Error(appErr)
-		return nil, appErr
+		return nil, 0, appErr
 	}
 
-	usr.log.Info(user)
-	usr.log.Info(len(user.Words))
-	return user.Words, nil
+	return words, total, nil
 }
 
-func (usr *repoUsers) GetLearnByIDAndLimit(ctx context.Context, id *uuid.UUID, limit int) ([]*models.Word, error) {
-	var user *models.User
-	err := usr.db.Preload("Learn", func(db *gorm.DB) *gorm.DB {
-		return db.Limit(limit)
-	}).Where("id = ?", id).Find(&user).Error
+func (usr *repoUsers) GetLearnByIDAndLimit(ctx context.Context, id *uuid.UUID, filter *WordsFilter) ([]*models.Word, int64, error) {
+	words, total, err := usr.findWordsPage(ctx, progressJoin, "progresses.in_learn", id, filter)
 	if err != nil {
 		appErr := apperrors.GetLearnByIDAndLimitErr.AppendMessage(err)
 		usr.log.Error(appErr)
-		return nil, appErr
+		return nil, 0, appErr
+	}
+
+	return words, total, nil
+}
+
+func (usr *repoUsers) GetLearnedByIDAndLimit(ctx context.Context, id *uuid.UUID, filter *WordsFilter) ([]*models.Word, int64, error) {
+	words, total, err := usr.findWordsPage(ctx, progressJoin, "progresses.learned", id, filter)
+	if err != nil {
+		appErr := apperrors.GetLearnedByIDAndLimitErr.AppendMessage(err)
+		usr.log.Error(appErr)
+		return nil, 0, appErr
+	}
+
+	return words, total, nil
+}
+
+const progressJoin = "JOIN progresses ON progresses.word_id = words.id AND progresses.user_id = ? AND progresses.deleted_at IS NULL"
+
+// findWordsPage counts the filtered list, then reads Limit words after the cursor ordered by created_at and id.
+func (usr *repoUsers) findWordsPage(ctx context.Context, join string, list string, id *uuid.UUID, filter *WordsFilter) ([]*models.Word, int64, error) {
+	query := usr.db.WithContext(ctx).Model(&models.Word{}).
+		Joins(join, id).
+		Where(list).
+		Where("words.language_from = ? AND words.language_to = ?", filter.From, filter.To)
+	if filter.Theme != "" {
+		query = query.Where("words.theme = ?", filter.Theme)
+	}
+
+	if filter.PartsOfSpeech != "" {
+		query = query.Where("words.parts_of_speech = ?", filter.PartsOfSpeech)
+	}
+
+	if filter.Search != "" {
+		search := "%" + escapeLike(filter.Search) + "%"
+		query = query.Where("words.english ILIKE ? OR words.russian ILIKE ?", search, search)
+	}
+
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.AfterID != nil {
+		query = query.Where("(words.created_at, words.id) > (?, ?)", filter.AfterCreatedAt, filter.AfterID)
 	}
 
-	usr.log.Info(user)
-	usr.log.Info(len(user.Learn))
-	return user.Learn, nil
+	var words []*models.Word
+	err := query.Order("words.created_at, words.id").Limit(filter.Limit).Find(&words).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return words, total, nil
 }
 
 func (usr *repoUsers) DeleteLearnWordFromUserByWordID(ctx context.Context, user *models.User, word *models.Word) error {
-	association := usr.db.Model(user).Association("Learn")
-	if association.Error != nil {
-		appErr := apperrors.DeleteLearnWordFromUserByWordErr.AppendMessage(association.Error)
+	err := usr.db.WithContext(ctx).Model(&models.Progress{}).
+		Where("user_id = ? AND word_id = ?", user.ID, word.ID).
+		Update("in_learn", false).Error
+	if err != nil {
+		appErr := apperrors.DeleteLearnWordFromUserByWordErr.AppendMessage(err)
 		usr.log.Error(appErr)
 		return appErr
 	}
 
-	if err := association.Delete(word); err != nil {
-		appErr := apperrors.DeleteLearnWordFromUserByWordErr.AppendMessage(err)
+	return nil
+}
+
+// ApplyWordOperations makes the changes of a batch in one transaction, an operation on a word which doesn't exist
+// is marked not found and skipped. A word moved to learned gets its review graded by schedule in the same transaction.
+// Any other failure rolls the whole batch back.
+func (usr *repoUsers) ApplyWordOperations(ctx context.Context, user *models.User, ops []*models.WordOperation, schedule func(review *models.Review)) error {
+	tx := usr.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		appErr := apperrors.ApplyWordOperationsErr.AppendMessage(tx.Error)
+		usr.log.Error(appErr)
+		return appErr
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	ids := make([]*uuid.UUID, 0, len(ops))
+	for _, op := range ops {
+		ids = append(ids, op.WordID)
+	}
+
+	var found []string
+	if err := tx.Model(&models.Word{}).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+		tx.Rollback()
+		appErr := apperrors.ApplyWordOperationsErr.AppendMessage(err)
+		usr.log.Error(appErr)
+		return appErr
+	}
+
+	exists := make(map[string]bool, len(found))
+	for _, id := range found {
+		exists[id] = true
+	}
+
+	for _, op := range ops {
+		if !exists[op.WordID.String()] {
+			op.Status = models.WordOperationNotFound
+			continue
+		}
+
+		if err := applyWordOperation(tx, user.ID, op, schedule); err != nil {
+			tx.Rollback()
+			appErr := apperrors.ApplyWordOperationsErr.AppendMessage(err)
+			usr.log.Error(appErr)
+			return appErr
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		appErr := apperrors.ApplyWordOperationsErr.AppendMessage(err)
+		usr.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+// applyWordOperation makes one change of the word list on db, a transaction of the caller, and marks it applied.
+// A word moved to learned gets its review graded by schedule.
+func applyWordOperation(db *gorm.DB, userID *uuid.UUID, op *models.WordOperation, schedule func(review *models.Review)) error {
+	var err error
+	switch op.Action {
+	case models.WordActionMoveToLearned:
+		err = saveProgress(db, &models.Progress{UserID: userID, WordID: op.WordID, Learned: true}, "learned")
+		if err == nil {
+			err = scheduleReview(db, userID, op.WordID, schedule)
+		}
+	case models.WordActionAddToLearn:
+		err = saveProgress(db, &models.Progress{UserID: userID, WordID: op.WordID, InLearn: true}, "in_learn")
+	case models.WordActionDeleteLearn:
+		err = db.Model(&models.Progress{}).
+			Where("user_id = ? AND word_id = ?", userID, op.WordID).
+			Update("in_learn", false).Error
+	default:
+		err = apperrors.BadRequestErr.AppendMessage("unknown action", op.Action)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	op.Status = models.WordOperationApplied
+	return nil
+}
+
+func (usr *repoUsers) UpdateUserRole(ctx context.Context, id *uuid.UUID, role string) error {
+	result := usr.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("role", role)
+	if result.Error != nil {
+		appErr := apperrors.UpdateUserRoleErr.AppendMessage(result.Error)
+		usr.log.Error(appErr)
+		return appErr
+	}
+
+	if result.RowsAffected == 0 {
+		appErr := apperrors.UpdateUserRoleErr.AppendMessage(&apperrors.UserNotFoundErr)
+		usr.log.Error(appErr)
+		return appErr
+	}
+
+	return nil
+}
+
+// GrantAdmins makes admins of the registered users among the emails and returns how many were promoted.
+func (usr *repoUsers) GrantAdmins(ctx context.Context, emails []string) (int64, error) {
+	result := usr.db.WithContext(ctx).Model(&models.User{}).
+		Where("email IN ? AND role <> ?", emails, models.RoleAdmin).
+		Update("role", models.RoleAdmin)
+	if result.Error != nil {
+		appErr := apperrors.GrantAdminsErr.AppendMessage(result.Error)
+		usr.log.Error(appErr)
+		return 0, appErr
+	}
+
+	return result.RowsAffected, nil
+}
+
+// CreateWords adds the words missing from the shared list of their pair.
+func (usr *repoUsers) CreateWords(ctx context.Context, words []*models.Word) (int, error) {
+	created := 0
+	err := usr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, word := range words {
+			result := tx.Where(models.Word{LanguageFrom: word.LanguageFrom, LanguageTo: word.LanguageTo, Russian: word.Russian, English: word.English}).
+				Attrs(models.Word{ID: word.ID, Theme: word.Theme, PartsOfSpeech: word.PartsOfSpeech}).
+				FirstOrCreate(word)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			created += int(result.RowsAffected)
+		}
+
+		return nil
+	})
+	if err != nil {
+		appErr := apperrors.AddWordsErr.AppendMessage(err)
+		usr.log.Error(appErr)
+		return 0, appErr
+	}
+
+	return created, nil
+}
+
+func (usr *repoUsers) UpdateUserLanguagePair(ctx context.Context, id *uuid.UUID, from string, to string) error {
+	result := usr.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"language_from": from, "language_to": to})
+	if result.Error != nil {
+		appErr := apperrors.UpdateUserLanguagePairErr.AppendMessage(result.Error)
+		usr.log.Error(appErr)
+		return appErr
+	}
+
+	if result.RowsAffected == 0 {
+		appErr := apperrors.UpdateUserLanguagePairErr.AppendMessage(&apperrors.UserNotFoundErr)
 		usr.log.Error(appErr)
 		return appErr
 	}
@@ -1,15 +1,23 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"server/internal/apperrors"
 	"server/internal/domain/mappers"
+	"server/internal/domain/models"
 	"server/internal/domain/requests"
 	"server/internal/domain/responses"
 	"server/internal/services"
+	"strconv"
+	"time"
 
+	"github.com/golang-jwt/jwt"
 	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
 )
 
 func (srv *server) createUserHandler() http.HandlerFunc {
@@ -20,17 +28,16 @@ func (srv *server) createUserHandler() http.HandlerFunc {
 		if err != nil {
 			appErr := apperrors.CreateUserHandlerErr.AppendMessage(err)
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusBadRequest)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
 			return
 		}
 
 		srv.logger.Infof("createUserHandler has been invoked. Name %v, Email %v", createUserRequest.Name, createUserRequest.Email)
-		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.logger)
+		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.repoLexemes, srv.logger)
 		getUserResp, err := userService.CreateUser(r.Context(), createUserRequest)
 		if err != nil {
-			appErr := err.(*apperrors.AppError)
-			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusInternalServerError)
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
 			return
 		}
 
@@ -47,25 +54,86 @@ func (srv *server) loginHandler() http.HandlerFunc {
 		if err != nil {
 			appErr := apperrors.LoginHandlerErr.AppendMessage(err)
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusBadRequest)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
 			return
 		}
 
-		srv.logger.Infof("loginHandler has been invoked.  Email %v", loginRequest.Email)
-		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.logger)
-		getUserResp, err := userService.SignInUserWithJWT(r.Context(), loginRequest, srv.config.Server.SecretKey, srv.config.Server.ExpirationJWTInSeconds)
+		srv.logger.Infof("loginHandler has been invoked.  Email %v, Device %v", loginRequest.Email, loginRequest.Device)
+		// The lockout is kept per email and address, a stranger can't lock the owner out of the account.
+		// The email bucket of rateLimit stays the brake on the guesses spread over many addresses.
+		lockoutKey := srv.keyByIP(r) + ":" + emailKey(loginRequest.Email)
+		lockedFor, err := srv.loginLockout.LockedFor(r.Context(), lockoutKey)
 		if err != nil {
-			appErr := err.(*apperrors.AppError)
+			appErr := apperrors.LoginLockoutErr.AppendMessage(err)
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusInternalServerError)
+			srv.respondErr(w, appErr, http.StatusInternalServerError)
+			return
+		}
+
+		// A locked login is refused before the password is hashed, a guess costs nothing then.
+		if lockedFor > 0 {
+			appErr := apperrors.LoginLockedErr.AppendMessage(loginRequest.Email)
+			srv.logger.Warn(appErr)
+			srv.respondTooManyRequests(w, appErr, lockedFor)
+			return
+		}
+
+		tokenService := services.NewTokenService(srv.repoUsers, srv.repoRefreshTokens, srv.logger)
+		getUserResp, err := tokenService.SignInUserWithJWT(r.Context(), loginRequest, srv.config.Server.SecretKey,
+			srv.config.Server.ExpirationJWTInSeconds, srv.config.Server.ExpirationRefreshInSeconds)
+		if errors.Is(err, &apperrors.InvalidCredentialsErr) {
+			lockedFor, lockoutErr := srv.loginLockout.Fail(r.Context(), lockoutKey)
+			if lockoutErr != nil {
+				srv.logger.Error(apperrors.LoginLockoutErr.AppendMessage(lockoutErr))
+			}
+
+			if lockedFor > 0 {
+				srv.logger.Warnf("loginHandler has locked %v for %v", lockoutKey, lockedFor)
+			}
+		}
+
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
 			return
 		}
 
-		srv.logger.Infof("loginHandler has been processed. Response: %+v", getUserResp)
+		if err := srv.loginLockout.Reset(r.Context(), lockoutKey); err != nil {
+			srv.logger.Error(apperrors.LoginLockoutErr.AppendMessage(err))
+		}
+
+		srv.logger.Info("loginHandler has been processed.")
 		srv.respond(w, getUserResp, http.StatusOK)
 	}
 }
 
+func (srv *server) refreshTokenHandler() http.HandlerFunc {
+	srv.logger.Info("refreshTokenHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		refreshTokenRequest := &requests.RefreshTokenRequest{}
+		err := srv.decode(r, refreshTokenRequest)
+		if err != nil {
+			appErr := apperrors.RefreshTokenHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		srv.logger.Info("refreshTokenHandler has been invoked.")
+		tokenService := services.NewTokenService(srv.repoUsers, srv.repoRefreshTokens, srv.logger)
+		loginResp, err := tokenService.RefreshTokens(r.Context(), refreshTokenRequest, srv.config.Server.SecretKey,
+			srv.config.Server.ExpirationJWTInSeconds, srv.config.Server.ExpirationRefreshInSeconds)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusUnauthorized)
+			return
+		}
+
+		srv.logger.Info("refreshTokenHandler has been processed.")
+		srv.respond(w, loginResp, http.StatusOK)
+	}
+}
+
 func (srv *server) logoutHandler() http.HandlerFunc {
 	srv.logger.Info("logoutHandler has been initiated.")
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -73,12 +141,34 @@ func (srv *server) logoutHandler() http.HandlerFunc {
 		if token == "" {
 			appErr := apperrors.LogoutHandlerErr.AppendMessage("HEADER GET Authorization")
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusBadRequest)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		err := srv.revokeAccessToken(r.Context(), token)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		refreshTokenRequest := &requests.RefreshTokenRequest{}
+		err = srv.decode(r, refreshTokenRequest)
+		if err != nil && !errors.Is(err, io.EOF) {
+			appErr := apperrors.LogoutHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		tokenService := services.NewTokenService(srv.repoUsers, srv.repoRefreshTokens, srv.logger)
+		err = tokenService.RevokeRefreshToken(r.Context(), refreshTokenRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
 			return
 		}
 
-		srv.blacklist.AddToken(token)
-		srv.logger.Info("Token has been blacklisted")
 		srv.respond(w, "token deleted", http.StatusOK)
 	}
 }
@@ -90,17 +180,23 @@ func (srv *server) getUserByIdHandler() http.HandlerFunc {
 		if !ok {
 			appErr := apperrors.GetUserByIdHandlerErr.AppendMessage("Vars User ID")
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusBadRequest)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		userID, err := srv.actingUserID(r, userID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
 			return
 		}
 
 		srv.logger.Infof("getUserByIdHandler has been invoked. Id %v", userID)
-		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.logger)
+		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.repoLexemes, srv.logger)
 		words, err := userService.GetUserById(r.Context(), userID)
 		if err != nil {
-			appErr := err.(*apperrors.AppError)
-			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusInternalServerError)
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
 			return
 		}
 
@@ -109,29 +205,81 @@ func (srv *server) getUserByIdHandler() http.HandlerFunc {
 	}
 }
 
+func (srv *server) changeUserRoleHandler() http.HandlerFunc {
+	srv.logger.Info("changeUserRoleHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := mux.Vars(r)["user_id"]
+		if !ok {
+			appErr := apperrors.ChangeUserRoleHandlerErr.AppendMessage("Vars User ID")
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		changeUserRoleRequest := &requests.ChangeUserRoleRequest{}
+		err := srv.decode(r, changeUserRoleRequest)
+		if err != nil {
+			appErr := apperrors.ChangeUserRoleHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actorID, _ := r.Context().Value(contextKeyID).(string)
+		srv.logger.Infof("changeUserRoleHandler has been invoked. Admin Id %v, User Id %v, Role %v", actorID, userID, changeUserRoleRequest.Role)
+		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.repoLexemes, srv.logger)
+		err = userService.ChangeUserRole(r.Context(), actorID, userID, changeUserRoleRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		result := &responses.Result{Answer: "success"}
+		srv.logger.Infof("changeUserRoleHandler has been processed. Response: %+v", result)
+		srv.respond(w, result, http.StatusOK)
+	}
+}
+
 func (srv *server) getWordsByUserIDAndLimitHandler() http.HandlerFunc {
 	srv.logger.Info("getWordsByUserIDAndLimitHandler has been initiated.")
 	return func(w http.ResponseWriter, r *http.Request) {
 		getWordsByUsIdAndLimitRequest := &requests.GetWordsByUsIdAndLimitRequest{}
-		err := srv.decode(r, getWordsByUsIdAndLimitRequest)
+		err := srv.decodeDeprecatedBody(w, r, getWordsByUsIdAndLimitRequest)
 		if err != nil {
 			appErr := apperrors.GetWordsByUserIDAndLimitHandlerErr.AppendMessage(err)
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusBadRequest)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
 			return
 		}
 
+		err = srv.wordsQuery(r, getWordsByUsIdAndLimitRequest)
+		if err != nil {
+			appErr := apperrors.GetWordsByUserIDAndLimitHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, getWordsByUsIdAndLimitRequest.ID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
+			return
+		}
+
+		getWordsByUsIdAndLimitRequest.ID = actingUserID
+
 		srv.logger.Infof("getWordsByUserIDAndLimitHandler has been invoked. Id %v, Limit %v", getWordsByUsIdAndLimitRequest.ID, getWordsByUsIdAndLimitRequest.Limit)
-		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.logger)
+		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.repoLexemes, srv.logger)
 		words, err := userService.GetWordsByUsIdAndLimit(r.Context(), getWordsByUsIdAndLimitRequest)
 		if err != nil {
-			appErr := err.(*apperrors.AppError)
-			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusInternalServerError)
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
 			return
 		}
 
-		srv.logger.Infof("getWordsByUserIDAndLimitHandler has been processed. Response: %+v", words)
+		srv.logger.Infof("getWordsByUserIDAndLimitHandler has been processed. Response: %v words, total %v", len(words.Words), words.Total)
 		srv.respond(w, words, http.StatusOK)
 	}
 }
@@ -140,25 +288,84 @@ func (srv *server) getLearnByUserIDAndLimitHandler() http.HandlerFunc {
 	srv.logger.Info("getLearnByUserIDAndLimitHandler has been initiated.")
 	return func(w http.ResponseWriter, r *http.Request) {
 		getWordsByUsIdAndLimitRequest := &requests.GetWordsByUsIdAndLimitRequest{}
-		err := srv.decode(r, getWordsByUsIdAndLimitRequest)
+		err := srv.decodeDeprecatedBody(w, r, getWordsByUsIdAndLimitRequest)
+		if err != nil {
+			appErr := apperrors.GetLearnByUserIDAndLimitHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		err = srv.wordsQuery(r, getWordsByUsIdAndLimitRequest)
 		if err != nil {
 			appErr := apperrors.GetLearnByUserIDAndLimitHandlerErr.AppendMessage(err)
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusBadRequest)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, getWordsByUsIdAndLimitRequest.ID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
 			return
 		}
 
+		getWordsByUsIdAndLimitRequest.ID = actingUserID
+
 		srv.logger.Infof("getLearnByUserIDAndLimitHandler has been invoked. Id %v, Limit %v", getWordsByUsIdAndLimitRequest.ID, getWordsByUsIdAndLimitRequest.Limit)
-		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.logger)
+		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.repoLexemes, srv.logger)
 		words, err := userService.GetLearnByUsIdAndLimit(r.Context(), getWordsByUsIdAndLimitRequest)
 		if err != nil {
-			appErr := err.(*apperrors.AppError)
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("getLearnByUserIDAndLimitHandler has been processed. Response: %v words, total %v", len(words.Words), words.Total)
+		srv.respond(w, words, http.StatusOK)
+	}
+}
+
+func (srv *server) getLearnedByUserIDAndLimitHandler() http.HandlerFunc {
+	srv.logger.Info("getLearnedByUserIDAndLimitHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		getWordsByUsIdAndLimitRequest := &requests.GetWordsByUsIdAndLimitRequest{}
+		err := srv.decodeDeprecatedBody(w, r, getWordsByUsIdAndLimitRequest)
+		if err != nil {
+			appErr := apperrors.GetLearnedByUserIDAndLimitHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		err = srv.wordsQuery(r, getWordsByUsIdAndLimitRequest)
+		if err != nil {
+			appErr := apperrors.GetLearnedByUserIDAndLimitHandlerErr.AppendMessage(err)
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusInternalServerError)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, getWordsByUsIdAndLimitRequest.ID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
+			return
+		}
+
+		getWordsByUsIdAndLimitRequest.ID = actingUserID
+
+		srv.logger.Infof("getLearnedByUserIDAndLimitHandler has been invoked. Id %v, Limit %v", getWordsByUsIdAndLimitRequest.ID, getWordsByUsIdAndLimitRequest.Limit)
+		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.repoLexemes, srv.logger)
+		words, err := userService.GetLearnedByUsIdAndLimit(r.Context(), getWordsByUsIdAndLimitRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
 			return
 		}
 
-		srv.logger.Infof("getLearnByUserIDAndLimitHandler has been processed. Response: %+v", words)
+		srv.logger.Infof("getLearnedByUserIDAndLimitHandler has been processed. Response: %v words, total %v", len(words.Words), words.Total)
 		srv.respond(w, words, http.StatusOK)
 	}
 }
@@ -171,17 +378,25 @@ func (srv *server) moveWordToLearnedHandler() http.HandlerFunc {
 		if err != nil {
 			appErr := apperrors.MoveWordToLearnedHandlerErr.AppendMessage(err)
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusBadRequest)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, deleteWordFromUserByIDRequest.UserID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
 			return
 		}
 
+		deleteWordFromUserByIDRequest.UserID = actingUserID
+
 		srv.logger.Infof("moveWordToLearnedHandler has been invoked. User Id %v, Word Id %v", deleteWordFromUserByIDRequest.UserID, deleteWordFromUserByIDRequest.WordID)
-		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.logger)
+		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.repoLexemes, srv.logger)
 		err = userService.MoveWordToLearned(r.Context(), deleteWordFromUserByIDRequest)
 		if err != nil {
-			appErr := err.(*apperrors.AppError)
-			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusInternalServerError)
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
 			return
 		}
 
@@ -199,17 +414,25 @@ func (srv *server) addWordToLearnHandler() http.HandlerFunc {
 		if err != nil {
 			appErr := apperrors.AddWordToLearnHandlerErr.AppendMessage(err)
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusBadRequest)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, deleteWordFromUserByIDRequest.UserID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
 			return
 		}
 
+		deleteWordFromUserByIDRequest.UserID = actingUserID
+
 		srv.logger.Infof("addWordToLearnHandler has been invoked. User Id %v, Word Id %v", deleteWordFromUserByIDRequest.UserID, deleteWordFromUserByIDRequest.WordID)
-		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.logger)
+		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.repoLexemes, srv.logger)
 		err = userService.AddWordToLearn(r.Context(), deleteWordFromUserByIDRequest)
 		if err != nil {
-			appErr := err.(*apperrors.AppError)
-			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusInternalServerError)
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
 			return
 		}
 
@@ -227,17 +450,25 @@ func (srv *server) deleteLearnByUserIDAndLearnIDHandler() http.HandlerFunc {
 		if err != nil {
 			appErr := apperrors.DeleteLearnByUserIDAndLearnIDHandlerErr.AppendMessage(err)
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusBadRequest)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, deleteWordFromUserByIDRequest.UserID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
 			return
 		}
 
+		deleteWordFromUserByIDRequest.UserID = actingUserID
+
 		srv.logger.Infof("deleteLearnByUserIDAndLearnIDHandler has been invoked. User Id %v, Word Id %v", deleteWordFromUserByIDRequest.UserID, deleteWordFromUserByIDRequest.WordID)
-		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.logger)
+		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.repoLexemes, srv.logger)
 		err = userService.DeleteLearnFromUserById(r.Context(), deleteWordFromUserByIDRequest)
 		if err != nil {
-			appErr := err.(*apperrors.AppError)
-			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusInternalServerError)
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
 			return
 		}
 
@@ -247,39 +478,916 @@ func (srv *server) deleteLearnByUserIDAndLearnIDHandler() http.HandlerFunc {
 	}
 }
 
-func (srv *server) getTranslationHandler() http.HandlerFunc {
-	srv.logger.Info("getTranslationHandler has been initiated.")
+// wordsBatchHandler applies a list of word list changes at once, the moved words start their reviews in the same transaction.
+func (srv *server) wordsBatchHandler() http.HandlerFunc {
+	srv.logger.Info("wordsBatchHandler has been initiated.")
 	return func(w http.ResponseWriter, r *http.Request) {
-		translationReq := &requests.TranslationRequest{}
-		err := srv.decode(r, translationReq)
+		batchRequest := &requests.WordsBatchRequest{}
+		err := srv.decode(r, batchRequest)
 		if err != nil {
-			appErr := apperrors.GetTranslationHandlerErr.AppendMessage(err)
+			appErr := apperrors.WordsBatchHandlerErr.AppendMessage(err)
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusBadRequest)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
 			return
 		}
 
-		srv.logger.Infof("getTranslationHandler has been invoked.  Word  %v", translationReq.Word)
-		libService := services.NewLibraryService(srv.repoLibrary, srv.logger)
-		words, err := libService.GetTranslationByWord(r.Context(), translationReq)
+		actingUserID, err := srv.actingUserID(r, batchRequest.UserID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
+			return
+		}
+
+		batchRequest.UserID = actingUserID
+
+		srv.logger.Infof("wordsBatchHandler has been invoked. User Id %v, operations %v", batchRequest.UserID, len(batchRequest.Operations))
+		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.repoLexemes, srv.logger)
+		batchResp, err := userService.ApplyWordOperations(r.Context(), batchRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("wordsBatchHandler has been processed. Applied %v of %v", batchResp.Applied, len(batchResp.Results))
+		srv.respond(w, batchResp, http.StatusOK)
+	}
+}
+
+func (srv *server) addLanguagePairHandler() http.HandlerFunc {
+	srv.logger.Info("addLanguagePairHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		addLanguagePairRequest := &requests.AddLanguagePairRequest{}
+		err := srv.decode(r, addLanguagePairRequest)
 		if err != nil {
-			appErr := err.(*apperrors.AppError)
+			appErr := apperrors.AddLanguagePairHandlerErr.AppendMessage(err)
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusInternalServerError)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
 			return
 		}
 
-		wordsResp := mappers.MapLibraryToWordsGetTranslResponse(words)
-		srv.logger.Infof("getTranslationHandler has been processed. Response : %v words", len(wordsResp))
-		srv.respond(w, wordsResp, http.StatusOK)
+		actingUserID, err := srv.actingUserID(r, addLanguagePairRequest.UserID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
+			return
+		}
+
+		addLanguagePairRequest.UserID = actingUserID
+		srv.logger.Infof("addLanguagePairHandler has been invoked. User Id %v, %v -> %v", addLanguagePairRequest.UserID, addLanguagePairRequest.From, addLanguagePairRequest.To)
+		userService := services.NewUserService(srv.repoUsers, srv.repoLibrary, srv.repoLexemes, srv.logger)
+		pairResp, err := userService.AddLanguagePair(r.Context(), addLanguagePairRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("addLanguagePairHandler has been processed. Response: %+v", pairResp)
+		srv.respond(w, pairResp, http.StatusOK)
 	}
 }
 
-func (srv *server) decode(r *http.Request, v interface{}) error {
-	return json.NewDecoder(r.Body).Decode(v)
+func (srv *server) getDueWordsByUserIDAndLimitHandler() http.HandlerFunc {
+	srv.logger.Info("getDueWordsByUserIDAndLimitHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		getWordsByUsIdAndLimitRequest := &requests.GetWordsByUsIdAndLimitRequest{}
+		err := srv.decodeDeprecatedBody(w, r, getWordsByUsIdAndLimitRequest)
+		if err != nil {
+			appErr := apperrors.GetDueWordsHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		err = srv.wordsQuery(r, getWordsByUsIdAndLimitRequest)
+		if err != nil {
+			appErr := apperrors.GetDueWordsHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, getWordsByUsIdAndLimitRequest.ID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
+			return
+		}
+
+		getWordsByUsIdAndLimitRequest.ID = actingUserID
+
+		srv.logger.Infof("getDueWordsByUserIDAndLimitHandler has been invoked. Id %v, Limit %v", getWordsByUsIdAndLimitRequest.ID, getWordsByUsIdAndLimitRequest.Limit)
+		reviewService := services.NewReviewService(srv.repoReviews, srv.logger)
+		words, err := reviewService.GetDueWordsByUsIdAndLimit(r.Context(), getWordsByUsIdAndLimitRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("getDueWordsByUserIDAndLimitHandler has been processed. Response: %v words", len(words))
+		srv.respond(w, words, http.StatusOK)
+	}
 }
 
-func (srv *server) respond(w http.ResponseWriter, data interface{}, status int) {
+func (srv *server) answerReviewHandler() http.HandlerFunc {
+	srv.logger.Info("answerReviewHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		reviewAnswerRequest := &requests.ReviewAnswerRequest{}
+		err := srv.decode(r, reviewAnswerRequest)
+		if err != nil {
+			appErr := apperrors.AnswerReviewHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, reviewAnswerRequest.UserID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
+			return
+		}
+
+		reviewAnswerRequest.UserID = actingUserID
+
+		srv.logger.Infof("answerReviewHandler has been invoked. User Id %v, Word Id %v, Quality %v", reviewAnswerRequest.UserID, reviewAnswerRequest.WordID, reviewAnswerRequest.Quality)
+		reviewService := services.NewReviewService(srv.repoReviews, srv.logger)
+		review, err := reviewService.AnswerReview(r.Context(), reviewAnswerRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("answerReviewHandler has been processed. Response: %+v", review)
+		srv.respond(w, review, http.StatusOK)
+	}
+}
+
+func (srv *server) startQuizSessionHandler() http.HandlerFunc {
+	srv.logger.Info("startQuizSessionHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		startQuizSessionRequest := &requests.StartQuizSessionRequest{}
+		err := srv.decode(r, startQuizSessionRequest)
+		if err != nil {
+			appErr := apperrors.StartQuizSessionHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, startQuizSessionRequest.UserID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
+			return
+		}
+
+		startQuizSessionRequest.UserID = actingUserID
+
+		srv.logger.Infof("startQuizSessionHandler has been invoked. User Id %v, Mode %v", startQuizSessionRequest.UserID, startQuizSessionRequest.Mode)
+		quizService := services.NewQuizService(srv.repoQuiz, srv.repoUsers, srv.logger)
+		session, err := quizService.StartSession(r.Context(), startQuizSessionRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("startQuizSessionHandler has been processed. Response: %+v", session)
+		srv.respond(w, session, http.StatusCreated)
+	}
+}
+
+func (srv *server) quizAnswerHandler() http.HandlerFunc {
+	srv.logger.Info("quizAnswerHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		quizAnswerRequest := &requests.QuizAnswerRequest{}
+		err := srv.decodeJSON(r, quizAnswerRequest)
+		if err == nil {
+			quizAnswerRequest.SessionID = mux.Vars(r)["session_id"]
+			err = requests.Validate(quizAnswerRequest)
+		}
+
+		if err != nil {
+			appErr := apperrors.QuizAnswerHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, quizAnswerRequest.UserID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
+			return
+		}
+
+		quizAnswerRequest.UserID = actingUserID
+
+		srv.logger.Infof("quizAnswerHandler has been invoked. Session Id %v, Word Id %v, Correct %v", quizAnswerRequest.SessionID, quizAnswerRequest.WordID, quizAnswerRequest.Correct)
+		quizService := services.NewQuizService(srv.repoQuiz, srv.repoUsers, srv.logger)
+		err = quizService.RecordAnswer(r.Context(), quizAnswerRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		result := &responses.Result{Answer: "success"}
+		srv.logger.Infof("quizAnswerHandler has been processed. Response: %+v", result)
+		srv.respond(w, result, http.StatusCreated)
+	}
+}
+
+func (srv *server) nextQuizPromptHandler() http.HandlerFunc {
+	srv.logger.Info("nextQuizPromptHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		nextQuizPromptRequest := &requests.NextQuizPromptRequest{SessionID: mux.Vars(r)["session_id"]}
+		srv.queryParams(r, map[string]*string{"user_id": &nextQuizPromptRequest.UserID})
+		err := requests.Validate(nextQuizPromptRequest)
+		if err != nil {
+			appErr := apperrors.NextQuizPromptHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, nextQuizPromptRequest.UserID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
+			return
+		}
+
+		nextQuizPromptRequest.UserID = actingUserID
+
+		srv.logger.Infof("nextQuizPromptHandler has been invoked. Session Id %v", nextQuizPromptRequest.SessionID)
+		quizService := services.NewQuizService(srv.repoQuiz, srv.repoUsers, srv.logger)
+		prompt, err := quizService.NextPrompt(r.Context(), nextQuizPromptRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("nextQuizPromptHandler has been processed. Position %v, Done %v", prompt.Position, prompt.Done)
+		srv.respond(w, prompt, http.StatusOK)
+	}
+}
+
+func (srv *server) checkQuizAnswerHandler() http.HandlerFunc {
+	srv.logger.Info("checkQuizAnswerHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		checkQuizAnswerRequest := &requests.CheckQuizAnswerRequest{}
+		err := srv.decodeJSON(r, checkQuizAnswerRequest)
+		if err == nil {
+			checkQuizAnswerRequest.SessionID = mux.Vars(r)["session_id"]
+			err = requests.Validate(checkQuizAnswerRequest)
+		}
+
+		if err != nil {
+			appErr := apperrors.CheckQuizAnswerHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, checkQuizAnswerRequest.UserID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
+			return
+		}
+
+		checkQuizAnswerRequest.UserID = actingUserID
+
+		srv.logger.Infof("checkQuizAnswerHandler has been invoked. Session Id %v, Position %v", checkQuizAnswerRequest.SessionID, checkQuizAnswerRequest.Position)
+		quizService := services.NewQuizService(srv.repoQuiz, srv.repoUsers, srv.logger)
+		verdict, err := quizService.CheckAnswer(r.Context(), checkQuizAnswerRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("checkQuizAnswerHandler has been processed. Response: %+v", verdict)
+		srv.respond(w, verdict, http.StatusOK)
+	}
+}
+
+func (srv *server) finishQuizSessionHandler() http.HandlerFunc {
+	srv.logger.Info("finishQuizSessionHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		finishQuizSessionRequest := &requests.FinishQuizSessionRequest{}
+		err := srv.decodeJSON(r, finishQuizSessionRequest)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+
+		if err == nil {
+			finishQuizSessionRequest.SessionID = mux.Vars(r)["session_id"]
+			err = requests.Validate(finishQuizSessionRequest)
+		}
+
+		if err != nil {
+			appErr := apperrors.FinishQuizSessionHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, finishQuizSessionRequest.UserID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
+			return
+		}
+
+		finishQuizSessionRequest.UserID = actingUserID
+
+		srv.logger.Infof("finishQuizSessionHandler has been invoked. Session Id %v", finishQuizSessionRequest.SessionID)
+		quizService := services.NewQuizService(srv.repoQuiz, srv.repoUsers, srv.logger)
+		summary, err := quizService.FinishSession(r.Context(), finishQuizSessionRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("finishQuizSessionHandler has been processed. Response: %+v", summary)
+		srv.respond(w, summary, http.StatusOK)
+	}
+}
+
+func (srv *server) getStatsHandler() http.HandlerFunc {
+	srv.logger.Info("getStatsHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		statsRequest := &requests.StatsRequest{UserID: mux.Vars(r)["user_id"]}
+		srv.queryParams(r, map[string]*string{
+			"days": &statsRequest.Days,
+			"from": &statsRequest.From,
+			"to":   &statsRequest.To,
+		})
+
+		err := requests.Validate(statsRequest)
+		if err != nil {
+			appErr := apperrors.GetStatsHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		actingUserID, err := srv.actingUserID(r, statsRequest.UserID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusForbidden)
+			return
+		}
+
+		statsRequest.UserID = actingUserID
+
+		srv.logger.Infof("getStatsHandler has been invoked. User Id %v, Days %v", statsRequest.UserID, statsRequest.Days)
+		quizService := services.NewQuizService(srv.repoQuiz, srv.repoUsers, srv.logger)
+		stats, err := quizService.GetStats(r.Context(), statsRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("getStatsHandler has been processed. Response: %v answers", stats.Answers)
+		srv.respond(w, stats, http.StatusOK)
+	}
+}
+
+func (srv *server) getTranslationHandler() http.HandlerFunc {
+	srv.logger.Info("getTranslationHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		translationReq := &requests.TranslationRequest{}
+		err := srv.decodeDeprecatedBody(w, r, translationReq)
+		if err != nil {
+			appErr := apperrors.GetTranslationHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		srv.queryParams(r, map[string]*string{
+			"q":    &translationReq.Word,
+			"from": &translationReq.From,
+			"to":   &translationReq.To,
+		})
+		err = requests.Validate(translationReq)
+		if err != nil {
+			appErr := apperrors.GetTranslationHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		srv.logger.Infof("getTranslationHandler has been invoked.  Word  %v, From %v, To %v", translationReq.Word, translationReq.From, translationReq.To)
+		libService := services.NewLibraryService(srv.repoLibrary, srv.repoLexemes, srv.logger)
+		words, err := libService.GetTranslationByWord(r.Context(), translationReq)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		wordsResp := mappers.MapTranslationsToGetTranslResponse(words)
+		srv.logger.Infof("getTranslationHandler has been processed. Response : %v words", len(wordsResp))
+		srv.respond(w, wordsResp, http.StatusOK)
+	}
+}
+
+func (srv *server) searchTranslationHandler() http.HandlerFunc {
+	srv.logger.Info("searchTranslationHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		searchReq := &requests.SearchTranslationRequest{}
+		err := srv.decodeDeprecatedBody(w, r, searchReq)
+		if err != nil {
+			appErr := apperrors.SearchTranslationHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		srv.queryParams(r, map[string]*string{
+			"q":    &searchReq.Word,
+			"from": &searchReq.From,
+			"to":   &searchReq.To,
+		})
+		if limit := r.URL.Query().Get("limit"); limit != "" {
+			searchReq.Limit, err = strconv.Atoi(limit)
+			if err != nil {
+				appErr := apperrors.SearchTranslationHandlerErr.AppendMessage(apperrors.BadRequestErr.AppendMessage(err))
+				srv.logger.Error(appErr)
+				srv.respondErr(w, appErr, http.StatusBadRequest)
+				return
+			}
+		}
+
+		err = requests.Validate(searchReq)
+		if err != nil {
+			appErr := apperrors.SearchTranslationHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		srv.logger.Infof("searchTranslationHandler has been invoked.  Word  %v, From %v, To %v", searchReq.Word, searchReq.From, searchReq.To)
+		libService := services.NewLibraryService(srv.repoLibrary, srv.repoLexemes, srv.logger)
+		searchResp, err := libService.SearchTranslations(r.Context(), searchReq)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("searchTranslationHandler has been processed. Response : %v words, did you mean %v", len(searchResp.Results), searchResp.DidYouMean)
+		srv.respond(w, searchResp, http.StatusOK)
+	}
+}
+
+func (srv *server) getPhrasesHandler() http.HandlerFunc {
+	srv.logger.Info("getPhrasesHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		phrasesRequest := &requests.PhrasesRequest{}
+		srv.queryParams(r, map[string]*string{"theme": &phrasesRequest.Theme})
+		err := srv.queryInts(r, map[string]*int{"limit": &phrasesRequest.Limit})
+		if err == nil {
+			err = requests.Validate(phrasesRequest)
+		}
+
+		if err != nil {
+			appErr := apperrors.GetPhrasesHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		srv.logger.Infof("getPhrasesHandler has been invoked. Limit %v, Theme %v", phrasesRequest.Limit, phrasesRequest.Theme)
+		libService := services.NewLibraryService(srv.repoLibrary, srv.repoLexemes, srv.logger)
+		phrases, err := libService.GetPhrases(r.Context(), phrasesRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("getPhrasesHandler has been processed. Response: %v phrases", len(phrases))
+		srv.respond(w, phrases, http.StatusOK)
+	}
+}
+
+func (srv *server) checkPhraseHandler() http.HandlerFunc {
+	srv.logger.Info("checkPhraseHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		checkPhraseRequest := &requests.CheckPhraseRequest{}
+		err := srv.decodeJSON(r, checkPhraseRequest)
+		if err == nil {
+			checkPhraseRequest.PhraseID, err = strconv.Atoi(mux.Vars(r)["phrase_id"])
+		}
+
+		if err == nil {
+			err = requests.Validate(checkPhraseRequest)
+		}
+
+		if err != nil {
+			appErr := apperrors.CheckPhraseHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		srv.logger.Infof("checkPhraseHandler has been invoked. Id %v", checkPhraseRequest.PhraseID)
+		libService := services.NewLibraryService(srv.repoLibrary, srv.repoLexemes, srv.logger)
+		verdict, err := libService.CheckPhrase(r.Context(), checkPhraseRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("checkPhraseHandler has been processed. Response: %+v", verdict)
+		srv.respond(w, verdict, http.StatusOK)
+	}
+}
+
+func (srv *server) getLibraryWordHandler() http.HandlerFunc {
+	srv.logger.Info("getLibraryWordHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		wordID, err := strconv.Atoi(mux.Vars(r)["word_id"])
+		if err != nil {
+			appErr := apperrors.GetLibraryWordHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		srv.logger.Infof("getLibraryWordHandler has been invoked. Id %v", wordID)
+		libService := services.NewLibraryService(srv.repoLibrary, srv.repoLexemes, srv.logger)
+		word, err := libService.GetWordByID(r.Context(), wordID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("getLibraryWordHandler has been processed. Response: %+v", word)
+		srv.respond(w, word, http.StatusOK)
+	}
+}
+
+func (srv *server) createLibraryWordHandler() http.HandlerFunc {
+	srv.logger.Info("createLibraryWordHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		libraryWordRequest := &requests.LibraryWordRequest{}
+		err := srv.decode(r, libraryWordRequest)
+		if err != nil {
+			appErr := apperrors.CreateLibraryWordHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		srv.logger.Infof("createLibraryWordHandler has been invoked. English %v, Russian %v", libraryWordRequest.English, libraryWordRequest.Russian)
+		libService := services.NewLibraryService(srv.repoLibrary, srv.repoLexemes, srv.logger)
+		word, err := libService.CreateWord(r.Context(), libraryWordRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("createLibraryWordHandler has been processed. Response: %+v", word)
+		srv.respond(w, word, http.StatusCreated)
+	}
+}
+
+func (srv *server) updateLibraryWordHandler() http.HandlerFunc {
+	srv.logger.Info("updateLibraryWordHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		wordID, err := strconv.Atoi(mux.Vars(r)["word_id"])
+		if err != nil {
+			appErr := apperrors.UpdateLibraryWordHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		libraryWordRequest := &requests.LibraryWordRequest{}
+		err = srv.decode(r, libraryWordRequest)
+		if err != nil {
+			appErr := apperrors.UpdateLibraryWordHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		srv.logger.Infof("updateLibraryWordHandler has been invoked. Id %v, English %v, Russian %v", wordID, libraryWordRequest.English, libraryWordRequest.Russian)
+		libService := services.NewLibraryService(srv.repoLibrary, srv.repoLexemes, srv.logger)
+		word, err := libService.UpdateWord(r.Context(), wordID, libraryWordRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("updateLibraryWordHandler has been processed. Response: %+v", word)
+		srv.respond(w, word, http.StatusOK)
+	}
+}
+
+func (srv *server) deleteLibraryWordHandler() http.HandlerFunc {
+	srv.logger.Info("deleteLibraryWordHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		wordID, err := strconv.Atoi(mux.Vars(r)["word_id"])
+		if err != nil {
+			appErr := apperrors.DeleteLibraryWordHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		srv.logger.Infof("deleteLibraryWordHandler has been invoked. Id %v", wordID)
+		libService := services.NewLibraryService(srv.repoLibrary, srv.repoLexemes, srv.logger)
+		err = libService.DeleteWord(r.Context(), wordID)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		result := &responses.Result{Answer: "success"}
+		srv.logger.Infof("deleteLibraryWordHandler has been processed. Response: %+v", result)
+		srv.respond(w, result, http.StatusOK)
+	}
+}
+
+func (srv *server) createTranslationHandler() http.HandlerFunc {
+	srv.logger.Info("createTranslationHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		createTranslationRequest := &requests.CreateTranslationRequest{}
+		err := srv.decode(r, createTranslationRequest)
+		if err != nil {
+			appErr := apperrors.CreateTranslationHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		srv.logger.Infof("createTranslationHandler has been invoked. %v %v -> %v %v", createTranslationRequest.From,
+			createTranslationRequest.Word, createTranslationRequest.To, createTranslationRequest.Translation)
+		libService := services.NewLibraryService(srv.repoLibrary, srv.repoLexemes, srv.logger)
+		err = libService.CreateTranslation(r.Context(), createTranslationRequest)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		result := &responses.Result{Answer: "success"}
+		srv.logger.Infof("createTranslationHandler has been processed. Response: %+v", result)
+		srv.respond(w, result, http.StatusCreated)
+	}
+}
+
+func (srv *server) importLibraryHandler() http.HandlerFunc {
+	srv.logger.Info("importLibraryHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		library := []*models.Library{}
+		err := srv.decode(r, &library)
+		if err != nil {
+			appErr := apperrors.ImportLibraryHandlerErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		srv.logger.Infof("importLibraryHandler has been invoked. %v words", len(library))
+		libService := services.NewLibraryService(srv.repoLibrary, srv.repoLexemes, srv.logger)
+		importResp, err := libService.ImportWords(r.Context(), library)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respondErr(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		srv.logger.Infof("importLibraryHandler has been processed. Response: %+v", importResp)
+		srv.respond(w, importResp, http.StatusOK)
+	}
+}
+
+// revokeAccessToken stores the jti until the token expires. An invalid or expired token is already unusable.
+func (srv *server) revokeAccessToken(ctx context.Context, tokenString string) error {
+	token, err := srv.parseJWT(tokenString)
+	if err != nil || !token.Valid {
+		srv.logger.Infof("token isn't valid, nothing to revoke: %v", err)
+		return nil
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return nil
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return apperrors.LogoutHandlerErr.AppendMessage("Exp not found in token")
+	}
+
+	err = srv.revocationStore.Revoke(ctx, jti, time.Unix(int64(exp), 0))
+	if err != nil {
+		return err
+	}
+
+	srv.logger.Info("Token has been revoked")
+	return nil
+}
+
+// actingUserID resolves the user a request acts on from the token subject.
+// An empty requestedID means the caller itself, only admins may act on behalf of others and every such request is audited.
+func (srv *server) actingUserID(r *http.Request, requestedID string) (string, error) {
+	tokenID, _ := r.Context().Value(contextKeyID).(string)
+	if tokenID == "" {
+		return "", apperrors.ActingUserErr.AppendMessage("token subject is empty")
+	}
+
+	if requestedID == "" || requestedID == tokenID {
+		return tokenID, nil
+	}
+
+	role, _ := r.Context().Value(contextKeyRole).(string)
+	if role != models.RoleAdmin {
+		return "", apperrors.ActingUserErr.AppendMessage("token subject", tokenID, "doesn't match user_id", requestedID)
+	}
+
+	srv.logger.WithFields(logrus.Fields{
+		"audit":    "act_on_behalf",
+		"admin_id": tokenID,
+		"user_id":  requestedID,
+		"method":   r.Method,
+		"path":     r.URL.Path,
+	}).Warn("admin acts on behalf of another user")
+	return requestedID, nil
+}
+
+// wordsQuery lets the path and query parameters override the user, the filters and the page of the body,
+// then validates the request.
+func (srv *server) wordsQuery(r *http.Request, getWordsReq *requests.GetWordsByUsIdAndLimitRequest) error {
+	if userID, ok := mux.Vars(r)["user_id"]; ok {
+		getWordsReq.ID = userID
+	}
+
+	srv.queryParams(r, map[string]*string{
+		"from":           &getWordsReq.From,
+		"to":             &getWordsReq.To,
+		"theme":          &getWordsReq.Theme,
+		"part_of_speech": &getWordsReq.PartsOfSpeech,
+		"q":              &getWordsReq.Search,
+		"cursor":         &getWordsReq.Cursor,
+	})
+	if err := srv.queryInts(r, map[string]*int{"limit": &getWordsReq.Limit}); err != nil {
+		return err
+	}
+
+	return requests.Validate(getWordsReq)
+}
+
+func (srv *server) queryParams(r *http.Request, fields map[string]*string) {
+	query := r.URL.Query()
+	for key, field := range fields {
+		if query.Has(key) {
+			*field = query.Get(key)
+		}
+	}
+}
+
+// queryInts reads the numeric query parameters, one which isn't a number fails like a validate tag.
+func (srv *server) queryInts(r *http.Request, fields map[string]*int) error {
+	query := r.URL.Query()
+	for key, field := range fields {
+		if !query.Has(key) {
+			continue
+		}
+
+		value, err := strconv.Atoi(query.Get(key))
+		if err != nil {
+			return apperrors.ValidationErr.AppendMessage(apperrors.FieldErrors{{Field: key, Message: "must be a number"}})
+		}
+
+		*field = value
+	}
+
+	return nil
+}
+
+// decodeDeprecatedBody reads the JSON body the old clients send on GET, the query parameters take its place.
+// It doesn't validate, the handler validates once the query parameters are applied.
+func (srv *server) decodeDeprecatedBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	err := srv.decodeJSON(r, v)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Deprecation", "true")
+	srv.logger.Warnf("%v %v sent a GET body, it is deprecated in favour of the query parameters", r.Method, r.URL.Path)
+	return nil
+}
+
+// decode reads the JSON body and checks the validate tags of the request.
+func (srv *server) decode(r *http.Request, v interface{}) error {
+	err := srv.decodeJSON(r, v)
+	if err != nil {
+		return err
+	}
+
+	return requests.Validate(v)
+}
+
+func (srv *server) decodeJSON(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		return apperrors.BadRequestErr.AppendMessage(err)
+	}
+
+	return nil
+}
+
+// healthzHandler answers while the process serves HTTP, it doesn't touch the database.
+func (srv *server) healthzHandler() http.HandlerFunc {
+	srv.logger.Info("healthzHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		srv.respond(w, &responses.HealthResponse{Status: "ok"}, http.StatusOK)
+	}
+}
+
+const readinessTimeout = 2 * time.Second
+
+// readyzHandler answers 503 until Postgres answers a ping.
+func (srv *server) readyzHandler() http.HandlerFunc {
+	srv.logger.Info("readyzHandler has been initiated.")
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		err := srv.psglDB.Ping(ctx)
+		if err != nil {
+			srv.logger.Error(err)
+			srv.respond(w, &responses.HealthResponse{Status: "unavailable"}, http.StatusServiceUnavailable)
+			return
+		}
+
+		srv.respond(w, &responses.HealthResponse{Status: "ok"}, http.StatusOK)
+	}
+}
+
+// respondErr answers with the problem document of err, the status of the error chain wins over fallback.
+func (srv *server) respondErr(w http.ResponseWriter, err error, fallback int) {
+	status, code := apperrors.Status(err, fallback)
+	detail := err.Error()
+	if appErr, ok := err.(*apperrors.AppError); ok {
+		detail = appErr.Message
+	}
+
+	problem := &responses.ProblemResponse{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+
+	var fieldErrs apperrors.FieldErrors
+	if errors.As(err, &fieldErrs) {
+		for _, fieldErr := range fieldErrs {
+			problem.Errors = append(problem.Errors, &responses.FieldErrorResponse{Field: fieldErr.Field, Message: fieldErr.Message})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	srv.respond(w, problem, status)
+}
+
+// respond writes data as JSON, a Content-Type set before, the problem one of respondErr, is kept.
+func (srv *server) respond(w http.ResponseWriter, data interface{}, status int) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
 	w.WriteHeader(status)
 	if data == nil {
 		return
@@ -1,13 +1,26 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net"
 	"net/http"
 	"server/internal/apperrors"
+	"server/internal/domain/models"
+	"server/internal/domain/requests"
+	"server/internal/metrics"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/gorilla/mux"
 )
 
 type contextKey string
@@ -17,6 +30,288 @@ const (
 	contextKeyID   contextKey = "id"
 )
 
+// roleRanks orders the roles, a role passes every guard of a lower rank.
+var roleRanks = map[string]int{
+	models.RoleUser:    1,
+	models.RoleTeacher: 2,
+	models.RoleAdmin:   3,
+}
+
+// statusRecorder keeps the status code written by the handler for the metrics.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// metricsMiddleware counts the requests by the route template, so the ids in the path don't blow up the labels.
+func (srv *server) metricsMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		h.ServeHTTP(rec, r)
+
+		metrics.RequestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		metrics.RequestsTotal.WithLabelValues(route, r.Method, strconv.Itoa(rec.status)).Inc()
+	})
+}
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	idempotentReplayed   = "Idempotent-Replayed"
+	maxIdempotencyKey    = 255
+	idempotencyKeyTTL    = 24 * time.Hour
+	// maxIdempotentBody caps the body idempotent keeps in memory to hash, a batch of 100 operations is about 10 KiB.
+	maxIdempotentBody = 1 << 20
+	// idempotencyKeyLease is how long a key stays in progress, a retry takes over a key whose request has died.
+	idempotencyKeyLease = time.Minute
+)
+
+// idempotentRecorder keeps a copy of the response so a retry with the same key gets it again.
+type idempotentRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotentRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotentRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// idempotent has to be wrapped by jwtAuthentication, the keys are kept per user. A request with an Idempotency-Key
+// runs once, a retry with the same key and body gets the stored response with the Idempotent-Replayed header.
+// A request without the header runs as usual, a response with a 5xx status frees the key for a retry.
+// The key is leased while the request runs, a request which never answers holds it for idempotencyKeyLease only.
+// A keyed request with a body over maxIdempotentBody is refused with 413.
+func (srv *server) idempotent(h http.HandlerFunc) http.HandlerFunc {
+	srv.logger.Info("idempotent")
+	return func(w http.ResponseWriter, r *http.Request) {
+		keyHeader := r.Header.Get(idempotencyKeyHeader)
+		if keyHeader == "" {
+			h(w, r)
+			return
+		}
+
+		if len(keyHeader) > maxIdempotencyKey {
+			appErr := apperrors.IdempotencyKeyErr.AppendMessage(len(keyHeader))
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			appErr := apperrors.RequestTooLargeErr.AppendMessage(maxBytesErr.Limit)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err != nil {
+			appErr := apperrors.BadRequestErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusBadRequest)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		userID, _ := r.Context().Value(contextKeyID).(string)
+		now := time.Now()
+		key := &models.IdempotencyKey{
+			UserID:      userID,
+			Key:         keyHeader,
+			Fingerprint: requestFingerprint(r, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(idempotencyKeyLease),
+		}
+
+		stored, err := srv.repoIdempotency.ReserveKey(r.Context(), key)
+		if err != nil {
+			appErr := apperrors.IdempotencyMiddleware.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusInternalServerError)
+			return
+		}
+
+		if stored != nil {
+			srv.replayIdempotent(w, stored, key)
+			return
+		}
+
+		defer func() {
+			if p := recover(); p != nil {
+				srv.releaseIdempotencyKey(key)
+				panic(p)
+			}
+		}()
+
+		rec := &idempotentRecorder{ResponseWriter: w, status: http.StatusOK}
+		h(rec, r)
+
+		if rec.status >= http.StatusInternalServerError {
+			srv.releaseIdempotencyKey(key)
+			return
+		}
+
+		key.Status = rec.status
+		key.ContentType = rec.Header().Get("Content-Type")
+		key.Body = rec.body.Bytes()
+		key.ExpiresAt = time.Now().Add(idempotencyKeyTTL)
+		if err := srv.repoIdempotency.SaveResponse(r.Context(), key); err != nil {
+			srv.logger.Error(err)
+			srv.releaseIdempotencyKey(key)
+		}
+	}
+}
+
+// releaseIdempotencyKey frees the key of a request which has no response to replay,
+// it doesn't use the request context which may be done by then.
+func (srv *server) releaseIdempotencyKey(key *models.IdempotencyKey) {
+	ctx, cancel := context.WithTimeout(context.Background(), idempotencyKeyLease)
+	defer cancel()
+
+	if err := srv.repoIdempotency.ReleaseKey(ctx, key); err != nil {
+		srv.logger.Error(err)
+	}
+}
+
+// replayIdempotent answers a retry with the stored response, or tells why it can't be replayed.
+func (srv *server) replayIdempotent(w http.ResponseWriter, stored *models.IdempotencyKey, key *models.IdempotencyKey) {
+	if stored.Fingerprint != key.Fingerprint {
+		appErr := apperrors.IdempotencyKeyReusedErr.AppendMessage(key.Key)
+		srv.logger.Error(appErr)
+		srv.respondErr(w, appErr, http.StatusUnprocessableEntity)
+		return
+	}
+
+	if stored.Status == 0 {
+		appErr := apperrors.IdempotencyKeyInProgressErr.AppendMessage(key.Key)
+		srv.logger.Error(appErr)
+		srv.respondErr(w, appErr, http.StatusConflict)
+		return
+	}
+
+	srv.logger.Infof("the response to the idempotency key %v of user %v is replayed", key.Key, key.UserID)
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+
+	w.Header().Set(idempotentReplayed, "true")
+	w.WriteHeader(stored.Status)
+	if _, err := w.Write(stored.Body); err != nil {
+		srv.logger.Error(err)
+	}
+}
+
+// requestFingerprint tells a retry from another request sent with the same key.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// securityHeaders sets the defaults a browser needs to treat the JSON API safely.
+func (srv *server) securityHeaders(h http.Handler) http.Handler {
+	cors := srv.config.Server.CORS
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("Referrer-Policy", "no-referrer")
+		if cors.ContentSecurityPolicy != "" {
+			header.Set("Content-Security-Policy", cors.ContentSecurityPolicy)
+		}
+
+		if cors.HSTSMaxAgeInSeconds != "" && cors.HSTSMaxAgeInSeconds != "0" {
+			header.Set("Strict-Transport-Security", "max-age="+cors.HSTSMaxAgeInSeconds+"; includeSubDomains")
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// cors answers the OPTIONS requests with the methods of the path and lets the allowed origins read the responses.
+// A preflight from an origin which isn't allowed, or for a method or a header which isn't, gets 403.
+func (srv *server) cors(h http.Handler) http.Handler {
+	cors := srv.config.Server.CORS
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := origin != "" && (containsFold(cors.AllowedOrigins, "*") || containsFold(cors.AllowedOrigins, origin))
+		if origin != "" {
+			w.Header().Add("Vary", "Origin")
+		}
+
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if len(cors.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(cors.ExposedHeaders, ", "))
+			}
+		}
+
+		if r.Method != http.MethodOptions {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		methods := srv.router.AllowedMethods(r)
+		if len(methods) == 0 {
+			appErr := apperrors.CORSMiddleware.AppendMessage("no route for", r.URL.Path)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Allow", strings.Join(append(methods, http.MethodOptions), ", "))
+		requestedMethod := r.Header.Get("Access-Control-Request-Method")
+		if origin == "" || requestedMethod == "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if !allowed || !containsFold(cors.AllowedMethods, requestedMethod) || !containsFold(methods, requestedMethod) {
+			appErr := apperrors.CORSMiddleware.AppendMessage("origin", origin, "method", requestedMethod)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusForbidden)
+			return
+		}
+
+		for _, requested := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+			if requested = strings.TrimSpace(requested); requested != "" && !containsFold(cors.AllowedHeaders, requested) {
+				appErr := apperrors.CORSMiddleware.AppendMessage("origin", origin, "header", requested)
+				srv.logger.Error(appErr)
+				srv.respondErr(w, appErr, http.StatusForbidden)
+				return
+			}
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(cors.AllowedMethods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(cors.AllowedHeaders, ", "))
+		w.Header().Set("Access-Control-Max-Age", cors.MaxAgeInSeconds)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
 func (srv *server) contextExpire(h http.HandlerFunc) http.HandlerFunc {
 	srv.logger.Info("contextExpire")
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -35,49 +330,54 @@ func (srv *server) jwtAuthentication(h http.HandlerFunc) http.HandlerFunc {
 		if tokenGet == "" {
 			appErr := apperrors.JWTMiddleware.AppendMessage("Vars Authorization")
 			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusUnauthorized)
+			srv.respondErr(w, appErr, http.StatusUnauthorized)
 			return
 		}
 
-		if srv.blacklist.IsTokenBlacklisted(tokenGet) {
-			appErr := apperrors.JWTMiddleware.AppendMessage("Token is blacklisted")
-			srv.logger.Error(appErr)
-			srv.respond(w, appErr.Message, http.StatusUnauthorized)
+		token, err := srv.parseJWT(tokenGet)
+		if err != nil {
+			srv.logger.Error(err)
+			appErr := apperrors.JWTMiddleware.AppendMessage("Token is invalid")
+			srv.respondErr(w, appErr, http.StatusUnauthorized)
 			return
 		}
 
-		token, err := jwt.Parse(tokenGet, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				appErr := apperrors.JWTMiddleware.AppendMessage("invalid signature method")
+		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+			jti, ok := claims["jti"].(string)
+			if !ok {
+				appErr := apperrors.JWTMiddleware.AppendMessage("Jti not found in token")
 				srv.logger.Error(appErr)
-				return nil, appErr
+				srv.respondErr(w, appErr, http.StatusUnauthorized)
+				return
 			}
 
-			return []byte(srv.config.Server.SecretKey), nil
-		})
+			revoked, err := srv.revocationStore.IsRevoked(r.Context(), jti)
+			if err != nil {
+				appErr := apperrors.JWTMiddleware.AppendMessage(err)
+				srv.logger.Error(appErr)
+				srv.respondErr(w, appErr, http.StatusInternalServerError)
+				return
+			}
 
-		if err != nil {
-			srv.logger.Error(err)
-			appErr := apperrors.JWTMiddleware.AppendMessage("Token is invalid")
-			srv.respond(w, appErr.Message, http.StatusUnauthorized)
-			return
-		}
+			if revoked {
+				appErr := apperrors.JWTMiddleware.AppendMessage("Token has been revoked")
+				srv.logger.Error(appErr)
+				srv.respondErr(w, appErr, http.StatusUnauthorized)
+				return
+			}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
 			role, ok := claims["role"].(string)
 			if !ok {
 				appErr := apperrors.JWTMiddleware.AppendMessage("Role not found in token")
-				w.WriteHeader(http.StatusBadRequest)
-				err := json.NewEncoder(w).Encode(appErr.Message)
-				if err != nil {
-					srv.logger.Error(appErr)
-				}
+				srv.logger.Error(appErr)
+				srv.respondErr(w, appErr, http.StatusUnauthorized)
+				return
 			}
 
 			id, ok := claims["id"].(string)
 			if !ok {
 				appErr := apperrors.JWTMiddleware.AppendMessage("Id not found in token")
-				srv.respond(w, appErr.Message, http.StatusUnauthorized)
+				srv.respondErr(w, appErr, http.StatusUnauthorized)
 				return
 			}
 
@@ -86,7 +386,7 @@ func (srv *server) jwtAuthentication(h http.HandlerFunc) http.HandlerFunc {
 			if err != nil {
 				appErr := apperrors.JWTMiddleware.AppendMessage("Parse duration err").AppendMessage(err)
 				srv.logger.Error(appErr)
-				srv.respond(w, appErr.Message, http.StatusUnauthorized)
+				srv.respondErr(w, appErr, http.StatusUnauthorized)
 				return
 			}
 
@@ -102,24 +402,142 @@ func (srv *server) jwtAuthentication(h http.HandlerFunc) http.HandlerFunc {
 		}
 
 		appErr := apperrors.JWTMiddleware.AppendMessage("The token has expired or is invalid")
-		srv.respond(w, appErr.Message, http.StatusUnauthorized)
+		srv.respondErr(w, appErr, http.StatusUnauthorized)
 	}
 }
 
-type blacklist struct {
-	tokens map[string]bool
+// requireRole has to be wrapped by jwtAuthentication, it reads the role claim from the context.
+func (srv *server) requireRole(minRole string, h http.HandlerFunc) http.HandlerFunc {
+	srv.logger.Infof("requireRole %v", minRole)
+	return func(w http.ResponseWriter, r *http.Request) {
+		role, _ := r.Context().Value(contextKeyRole).(string)
+		if roleRanks[role] < roleRanks[minRole] {
+			appErr := apperrors.RequireRoleMiddleware.AppendMessage("role", role, "required", minRole)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusForbidden)
+			return
+		}
+
+		h(w, r)
+	}
 }
 
-func newBlacklist() *blacklist {
-	return &blacklist{
-		tokens: make(map[string]bool),
+// rateKey names the bucket of a request, an empty key isn't limited.
+type rateKey func(r *http.Request) string
+
+// rateLimit answers 429 with Retry-After once the bucket of the key is empty, name keeps the buckets of a limit apart.
+func (srv *server) rateLimit(name string, limit models.RateLimit, key rateKey, h http.HandlerFunc) http.HandlerFunc {
+	srv.logger.Infof("rateLimit %v", name)
+	return func(w http.ResponseWriter, r *http.Request) {
+		bucketKey := key(r)
+		if bucketKey == "" {
+			h(w, r)
+			return
+		}
+
+		retryAfter, err := srv.rateLimiter.Take(r.Context(), name+":"+bucketKey, limit)
+		if err != nil {
+			appErr := apperrors.RateLimiterErr.AppendMessage(err)
+			srv.logger.Error(appErr)
+			srv.respondErr(w, appErr, http.StatusInternalServerError)
+			return
+		}
+
+		if retryAfter > 0 {
+			metrics.RequestsLimited.WithLabelValues(name).Inc()
+			appErr := apperrors.RateLimitMiddleware.AppendMessage(name, bucketKey)
+			srv.logger.Warn(appErr)
+			srv.respondTooManyRequests(w, appErr, retryAfter)
+			return
+		}
+
+		h(w, r)
 	}
 }
 
-func (b *blacklist) AddToken(token string) {
-	b.tokens[token] = true
+func (srv *server) respondTooManyRequests(w http.ResponseWriter, err error, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	srv.respondErr(w, err, http.StatusTooManyRequests)
 }
 
-func (b *blacklist) IsTokenBlacklisted(token string) bool {
-	return b.tokens[token]
+// keyByIP takes the address of the connection. Behind trusted proxies it counts their hops from the right
+// of X-Forwarded-For, the entries left of them are written by the client and can't be trusted.
+func (srv *server) keyByIP(r *http.Request) string {
+	if hops := srv.config.Server.RateLimit.TrustedProxyHops; hops > 0 {
+		forwarded := []string{}
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, entry := range strings.Split(header, ",") {
+				if entry = strings.TrimSpace(entry); entry != "" {
+					forwarded = append(forwarded, entry)
+				}
+			}
+		}
+
+		if len(forwarded) > 0 {
+			if hops > len(forwarded) {
+				hops = len(forwarded)
+			}
+
+			return "ip:" + forwarded[len(forwarded)-hops]
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// keyByUser has to be wrapped by jwtAuthentication, it reads the user ID from the context.
+func (srv *server) keyByUser(r *http.Request) string {
+	userID, _ := r.Context().Value(contextKeyID).(string)
+	if userID == "" {
+		return ""
+	}
+
+	return "user:" + userID
+}
+
+// maxLoginBodyBytes caps the login body keyByEmail reads before any handler, a login is a few hundred bytes.
+const maxLoginBodyBytes = 4 << 10
+
+// keyByEmail reads the email of a login body and puts the body back for the handler.
+// A body over maxLoginBodyBytes isn't limited here, the handler fails to read it as well.
+func (srv *server) keyByEmail(r *http.Request) string {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxLoginBodyBytes)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return ""
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	loginRequest := &requests.LoginRequest{}
+	if err := json.Unmarshal(body, loginRequest); err != nil {
+		return ""
+	}
+
+	return emailKey(loginRequest.Email)
+}
+
+func emailKey(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return ""
+	}
+
+	return "email:" + email
+}
+
+func (srv *server) parseJWT(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			appErr := apperrors.JWTMiddleware.AppendMessage("invalid signature method")
+			srv.logger.Error(appErr)
+			return nil, appErr
+		}
+
+		return []byte(srv.config.Server.SecretKey), nil
+	})
 }
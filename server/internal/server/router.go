@@ -2,6 +2,7 @@ package server
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -12,13 +13,23 @@ type Router interface {
 	Post(string, http.HandlerFunc)
 	Put(string, http.HandlerFunc)
 	Delete(string, http.HandlerFunc)
+	Use(func(http.Handler) http.Handler)
+	Wrap(func(http.Handler) http.Handler)
+	AllowedMethods(r *http.Request) []string
 }
 
 type router struct {
-	mux *mux.Router
+	mux     *mux.Router
+	chain   []func(http.Handler) http.Handler
+	handler http.Handler
 }
 
 func (router *router) ServeHttp(w http.ResponseWriter, r *http.Request) {
+	if router.handler != nil {
+		router.handler.ServeHTTP(w, r)
+		return
+	}
+
 	router.mux.ServeHTTP(w, r)
 }
 
@@ -37,3 +48,44 @@ func (router *router) Put(path string, handlerFunc http.HandlerFunc) {
 func (router *router) Delete(path string, handlerFunc http.HandlerFunc) {
 	router.mux.HandleFunc(path, handlerFunc).Methods(http.MethodDelete)
 }
+
+// Use adds a middleware which runs after the route is matched.
+func (router *router) Use(middleware func(http.Handler) http.Handler) {
+	router.mux.Use(middleware)
+}
+
+// Wrap adds a middleware which runs before the route is matched, it sees the requests no route takes,
+// the OPTIONS ones among them. The middleware wrapped first runs first.
+func (router *router) Wrap(middleware func(http.Handler) http.Handler) {
+	router.chain = append(router.chain, middleware)
+	var handler http.Handler = router.mux
+	for i := len(router.chain) - 1; i >= 0; i-- {
+		handler = router.chain[i](handler)
+	}
+
+	router.handler = handler
+}
+
+// AllowedMethods lists the methods the routes take for the path of the request.
+func (router *router) AllowedMethods(r *http.Request) []string {
+	methods := []string{}
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete} {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if router.mux.Match(probe, &mux.RouteMatch{}) {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,29 +2,51 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os/signal"
+	"server/internal/apperrors"
 	"server/internal/config"
 	"server/internal/database"
 	"server/internal/domain/models"
 	"server/internal/log"
+	"server/internal/metrics"
+	"server/internal/migrations"
 	"server/internal/repositories"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 type server struct {
-	repoLibrary repositories.RepoLibrary
-	repoUsers   repositories.RepoUsers
-	router      Router
-	logger      *logrus.Logger
-	config      *config.Config
-	blacklist   *blacklist
+	repoLibrary       repositories.RepoLibrary
+	repoUsers         repositories.RepoUsers
+	repoReviews       repositories.RepoReviews
+	repoLexemes       repositories.RepoLexemes
+	repoRefreshTokens repositories.RepoRefreshTokens
+	repoQuiz          repositories.RepoQuiz
+	repoIdempotency   repositories.RepoIdempotency
+	router            Router
+	logger            *logrus.Logger
+	config            *config.Config
+	revocationStore   repositories.RevocationStore
+	rateLimiter       repositories.RateLimiter
+	loginLockout      repositories.LoginLockout
+	psglDB            database.PostgresDB
 }
 
-func NewServer(repoLibrary repositories.RepoLibrary, repoUsers repositories.RepoUsers, logger *logrus.Logger, config *config.Config) *server {
-	return &server{repoLibrary: repoLibrary, repoUsers: repoUsers, router: &router{mux: mux.NewRouter()}, logger: logger, config: config}
+func NewServer(repoLibrary repositories.RepoLibrary, repoUsers repositories.RepoUsers, repoReviews repositories.RepoReviews, repoLexemes repositories.RepoLexemes,
+	repoRefreshTokens repositories.RepoRefreshTokens, repoQuiz repositories.RepoQuiz, repoIdempotency repositories.RepoIdempotency, revocationStore repositories.RevocationStore,
+	rateLimiter repositories.RateLimiter, loginLockout repositories.LoginLockout, psglDB database.PostgresDB, logger *logrus.Logger, config *config.Config) *server {
+	return &server{repoLibrary: repoLibrary, repoUsers: repoUsers, repoReviews: repoReviews, repoLexemes: repoLexemes, repoRefreshTokens: repoRefreshTokens,
+		repoQuiz: repoQuiz, repoIdempotency: repoIdempotency, revocationStore: revocationStore,
+		rateLimiter: rateLimiter, loginLockout: loginLockout, psglDB: psglDB, router: &router{mux: mux.NewRouter()}, logger: logger, config: config}
 }
 
 func (srv *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -33,83 +55,386 @@ func (srv *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 func (srv *server) initializeRoutes() {
 	srv.logger.Info("server INIT")
-	srv.router.Get("/library/translate", srv.contextExpire(srv.getTranslationHandler()))
+	limits := srv.config.Server.RateLimit
+	loginLimitByIP := models.RateLimit{PerMinute: limits.LoginPerMinuteByIP, Burst: limits.LoginBurstByIP}
+	loginLimitByEmail := models.RateLimit{PerMinute: limits.LoginPerMinuteByEmail, Burst: limits.LoginBurstByEmail}
+	signUpLimit := models.RateLimit{PerMinute: limits.SignUpPerMinuteByIP, Burst: limits.SignUpBurstByIP}
+	translateLimit := models.RateLimit{PerMinute: limits.TranslatePerMinuteByIP, Burst: limits.TranslateBurstByIP}
+	checkLimit := models.RateLimit{PerMinute: limits.CheckPerMinuteByUser, Burst: limits.CheckBurstByUser}
+	srv.router.Wrap(srv.securityHeaders)
+	srv.router.Wrap(srv.cors)
+	srv.router.Use(srv.metricsMiddleware)
+	srv.router.Get("/healthz", srv.healthzHandler())
+	srv.router.Get("/readyz", srv.readyzHandler())
 
-	srv.router.Post("/users", srv.contextExpire(srv.createUserHandler()))
-	srv.router.Post("/users/login", srv.contextExpire(srv.loginHandler()))
+	srv.router.Get("/library/translate", srv.rateLimit("translate", translateLimit, srv.keyByIP, srv.contextExpire(srv.getTranslationHandler())))
+	srv.router.Get("/library/search", srv.rateLimit("translate", translateLimit, srv.keyByIP, srv.contextExpire(srv.searchTranslationHandler())))
+	srv.router.Get("/library/phrases", srv.contextExpire(srv.getPhrasesHandler()))
+	srv.router.Post("/library/phrases/{phrase_id}/check", srv.rateLimit("phrase-check", translateLimit, srv.keyByIP, srv.contextExpire(srv.checkPhraseHandler())))
+	// Teachers keep the library up, deleting and importing words stay with the admins.
+	srv.router.Get("/library/words/{word_id}", srv.jwtAuthentication(srv.requireRole(models.RoleTeacher, srv.getLibraryWordHandler())))
+	srv.router.Post("/library/words", srv.jwtAuthentication(srv.requireRole(models.RoleTeacher, srv.createLibraryWordHandler())))
+	srv.router.Put("/library/words/{word_id}", srv.jwtAuthentication(srv.requireRole(models.RoleTeacher, srv.updateLibraryWordHandler())))
+	srv.router.Delete("/library/words/{word_id}", srv.jwtAuthentication(srv.requireRole(models.RoleAdmin, srv.deleteLibraryWordHandler())))
+	srv.router.Post("/library/translations", srv.jwtAuthentication(srv.requireRole(models.RoleTeacher, srv.createTranslationHandler())))
+	srv.router.Post("/library/import", srv.jwtAuthentication(srv.requireRole(models.RoleAdmin, srv.importLibraryHandler())))
 
-	blackList := newBlacklist()
-	srv.blacklist = blackList
+	srv.router.Post("/users", srv.rateLimit("sign-up", signUpLimit, srv.keyByIP, srv.contextExpire(srv.createUserHandler())))
+	srv.router.Post("/users/login", srv.rateLimit("login", loginLimitByIP, srv.keyByIP,
+		srv.rateLimit("login", loginLimitByEmail, srv.keyByEmail, srv.contextExpire(srv.loginHandler()))))
+	srv.router.Post("/users/token/refresh", srv.contextExpire(srv.refreshTokenHandler()))
 	srv.router.Post("/users/logout", srv.contextExpire(srv.logoutHandler()))
 	srv.router.Get("/users/{user_id}", srv.jwtAuthentication(srv.getUserByIdHandler()))
+	srv.router.Put("/users/{user_id}/role", srv.jwtAuthentication(srv.requireRole(models.RoleAdmin, srv.changeUserRoleHandler())))
+	srv.router.Get("/users/{user_id}/words", srv.jwtAuthentication(srv.getWordsByUserIDAndLimitHandler()))
+	srv.router.Get("/users/{user_id}/learn", srv.jwtAuthentication(srv.getLearnByUserIDAndLimitHandler()))
+	srv.router.Get("/users/{user_id}/learned", srv.jwtAuthentication(srv.getLearnedByUserIDAndLimitHandler()))
+	srv.router.Get("/users/{user_id}/review/due", srv.jwtAuthentication(srv.getDueWordsByUserIDAndLimitHandler()))
+	srv.router.Get("/users/{user_id}/stats", srv.jwtAuthentication(srv.getStatsHandler()))
+	// The /user read routes take the deprecated GET body, they stay for the old clients.
 	srv.router.Get("/user/words", srv.jwtAuthentication(srv.getWordsByUserIDAndLimitHandler()))
-	srv.router.Put("/user/move-word-to-learned", srv.jwtAuthentication(srv.moveWordToLearnedHandler()))
-	srv.router.Post("/user/add-word-to-learn", srv.jwtAuthentication(srv.addWordToLearnHandler()))
+	srv.router.Post("/user/words/batch", srv.jwtAuthentication(srv.idempotent(srv.wordsBatchHandler())))
+	srv.router.Put("/user/move-word-to-learned", srv.jwtAuthentication(srv.idempotent(srv.moveWordToLearnedHandler())))
+	srv.router.Post("/user/add-word-to-learn", srv.jwtAuthentication(srv.idempotent(srv.addWordToLearnHandler())))
 	srv.router.Get("/user/learn", srv.jwtAuthentication(srv.getLearnByUserIDAndLimitHandler()))
-	srv.router.Delete("/user/learn", srv.jwtAuthentication(srv.deleteLearnByUserIDAndLearnIDHandler()))
+	srv.router.Get("/user/learned", srv.jwtAuthentication(srv.getLearnedByUserIDAndLimitHandler()))
+	srv.router.Delete("/user/learn", srv.jwtAuthentication(srv.idempotent(srv.deleteLearnByUserIDAndLearnIDHandler())))
+	srv.router.Post("/user/language-pairs", srv.jwtAuthentication(srv.addLanguagePairHandler()))
+	srv.router.Get("/user/review/due", srv.jwtAuthentication(srv.getDueWordsByUserIDAndLimitHandler()))
+	srv.router.Post("/user/review/answer", srv.jwtAuthentication(srv.answerReviewHandler()))
+	srv.router.Post("/user/quiz-sessions", srv.jwtAuthentication(srv.idempotent(srv.startQuizSessionHandler())))
+	srv.router.Post("/user/quiz-sessions/{session_id}/answers", srv.jwtAuthentication(srv.idempotent(srv.quizAnswerHandler())))
+	srv.router.Get("/user/quiz-sessions/{session_id}/next", srv.jwtAuthentication(srv.nextQuizPromptHandler()))
+	srv.router.Post("/user/quiz-sessions/{session_id}/check", srv.jwtAuthentication(srv.rateLimit("check", checkLimit, srv.keyByUser, srv.checkQuizAnswerHandler())))
+	srv.router.Post("/user/quiz-sessions/{session_id}/finish", srv.jwtAuthentication(srv.finishQuizSessionHandler()))
+	srv.router.Get("/user/stats", srv.jwtAuthentication(srv.getStatsHandler()))
 
 }
 
+const (
+	revocationStoreMemory = "memory"
+	revokedTokensSweep    = 10 * time.Minute
+	idempotencyKeysSweep  = time.Hour
+	rateLimitsSweep       = 10 * time.Minute
+)
+
+func (srv *server) deleteExpiredRevokedTokens(ctx context.Context) {
+	ticker := time.NewTicker(revokedTokensSweep)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := srv.revocationStore.DeleteExpired(ctx); err != nil {
+				srv.logger.Error(err)
+			}
+		}
+	}
+}
+
+func (srv *server) deleteExpiredIdempotencyKeys(ctx context.Context) {
+	ticker := time.NewTicker(idempotencyKeysSweep)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := srv.repoIdempotency.DeleteExpired(ctx); err != nil {
+				srv.logger.Error(err)
+			}
+		}
+	}
+}
+
+func (srv *server) deleteIdleRateLimits(ctx context.Context) {
+	ticker := time.NewTicker(rateLimitsSweep)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := srv.rateLimiter.DeleteIdle(ctx); err != nil {
+				srv.logger.Error(err)
+			}
+
+			if err := srv.loginLockout.DeleteExpired(ctx); err != nil {
+				srv.logger.Error(err)
+			}
+		}
+	}
+}
+
 func Run() {
-	logger, err := log.NewLogAndSetLevel("info")
+	logger, cfg, psglDB, db := setup()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if cfg.Server.MigrateOnStart {
+		migrator, err := migrations.NewMigrator(db, logger)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		logger.Infof("Migration success, %v applied", applied)
+	}
+
+	err := seedLibrary(ctx, db, logger)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
-	cfg, err := config.NewConfig(logger)
+	repoLibrary := repositories.NewRepoLibrary(db, logger)
+	repoUser := repositories.NewRepoUsers(db, logger)
+	repoReviews := repositories.NewRepoReviews(db, logger)
+	repoRefreshTokens := repositories.NewRepoRefreshTokens(db, logger)
+	revocationStore := repositories.NewPgRevocationStore(db, logger)
+	if cfg.Server.RevocationStore == revocationStoreMemory {
+		revocationStore = repositories.NewMemoryRevocationStore()
+	}
+
+	err = grantAdmins(ctx, repoUser, cfg.Server.AdminEmails, logger)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
-	if err = log.SetLevel(logger, cfg.Postgres.LogLevel); err != nil {
+	repoLexemes := repositories.NewRepoLexemes(db, logger)
+	repoQuiz := repositories.NewRepoQuiz(db, logger)
+	repoIdempotency := repositories.NewRepoIdempotency(db, logger)
+	rateLimiter := repositories.NewMemoryRateLimiter()
+	limits := cfg.Server.RateLimit
+	loginLockout := repositories.NewMemoryLoginLockout(limits.LoginLockoutFailures,
+		time.Duration(limits.LoginLockoutInSeconds)*time.Second, time.Duration(limits.LoginMaxLockoutInSeconds)*time.Second)
+	sqlDB, err := db.DB()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	if err := metrics.RegisterDBStats(sqlDB); err != nil {
 		logger.Fatal(err)
 	}
 
+	srv := NewServer(repoLibrary, repoUser, repoReviews, repoLexemes, repoRefreshTokens, repoQuiz, repoIdempotency, revocationStore,
+		rateLimiter, loginLockout, psglDB, logger, cfg)
+	go srv.deleteExpiredRevokedTokens(ctx)
+	go srv.deleteExpiredIdempotencyKeys(ctx)
+	go srv.deleteIdleRateLimits(ctx)
+
+	srv.initializeRoutes()
+	httpServer, shutdownTimeout, err := newHTTPServer(cfg, srv)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	go func() {
+		logger.Infof("Listening HTTP service on %s port", cfg.AppPort)
+		err := httpServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal(err)
+		}
+	}()
+
+	metricsServer := newMetricsServer(cfg, httpServer.ReadHeaderTimeout)
+	go func() {
+		logger.Infof("Listening metrics on %s", cfg.Server.MetricsAddr)
+		err := metricsServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	logger.Infof("Shutting down, draining connections for up to %v", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error(err)
+	}
+
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error(err)
+	}
+
+	if err := psglDB.Close(); err != nil {
+		logger.Error(err)
+	}
+
+	logger.Info("Server stopped")
+}
+
+// newHTTPServer builds the http.Server with the timeouts of the config and returns the shutdown deadline.
+func newHTTPServer(cfg *config.Config, handler http.Handler) (*http.Server, time.Duration, error) {
+	timeouts := []string{cfg.Server.ReadHeaderTimeoutInSeconds, cfg.Server.ReadTimeoutInSeconds,
+		cfg.Server.WriteTimeoutInSeconds, cfg.Server.IdleTimeoutInSeconds, cfg.Server.ShutdownTimeoutInSeconds}
+	durations := make([]time.Duration, len(timeouts))
+	for i, timeout := range timeouts {
+		duration, err := time.ParseDuration(timeout + "s")
+		if err != nil {
+			return nil, 0, apperrors.EnvConfigParseError.AppendMessage(err)
+		}
+
+		durations[i] = duration
+	}
+
+	httpServer := &http.Server{
+		Addr:              fmt.Sprintf(":%s", cfg.AppPort),
+		Handler:           handler,
+		ReadHeaderTimeout: durations[0],
+		ReadTimeout:       durations[1],
+		WriteTimeout:      durations[2],
+		IdleTimeout:       durations[3],
+	}
+
+	return httpServer, durations[4], nil
+}
+
+// newMetricsServer serves /metrics on its own address, the traffic, the pool and the domain counters are for
+// the scraper only and the public port doesn't expose them.
+func newMetricsServer(cfg *config.Config, readHeaderTimeout time.Duration) *http.Server {
+	handler := http.NewServeMux()
+	handler.Handle("/metrics", metrics.Handler())
+	return &http.Server{
+		Addr:              cfg.Server.MetricsAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+}
+
+// Migrate runs the migrate up|down [steps]|status subcommand.
+func Migrate(args []string) {
+	logger, _, psglDB, db := setup()
+	defer psglDB.Close()
 	ctx := context.Background()
-	psglDB := database.NewPostgresDB()
-	db, err := psglDB.SetupDatabase(ctx, cfg, logger)
+	migrator, err := migrations.NewMigrator(db, logger)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
-	if !db.Migrator().HasTable(&models.Library{}) {
-		err = db.AutoMigrate(&models.Library{})
+	command := ""
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
 		if err != nil {
 			logger.Fatal(err)
 		}
 
-		repoBackup := repositories.NewBackUpCopyRepo("save_copy/library.json", "save_copy/library.txt", logger)
-		words, err := repoBackup.GetAllFromBackUp()
+		logger.Infof("Migration success, %v applied", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				logger.Fatalf("migrate down takes a positive number of steps, got %v", args[1])
+			}
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
 		if err != nil {
 			logger.Fatal(err)
 		}
 
-		repoLibrary := repositories.NewRepoLibrary(db, logger)
-		err = repoLibrary.InsertWordsLibrary(ctx, words)
+		logger.Infof("Migration success, %v reverted", reverted)
+	case "status":
+		statuses, err := migrator.Status(ctx)
 		if err != nil {
 			logger.Fatal(err)
 		}
 
-		logger.Info("Migration success")
-	}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
 
-	if !db.Migrator().HasTable(&models.User{}) {
-		err = db.AutoMigrate(&models.User{})
-		if err != nil {
-			logger.Fatal(err)
+			fmt.Printf("%04d  %-25s %v\n", status.Version, status.Name, appliedAt)
 		}
+	default:
+		logger.Fatalf("usage: server migrate up|down [steps]|status, got %q", command)
+	}
+}
 
-		logger.Info("Migration success")
+func setup() (*logrus.Logger, *config.Config, database.PostgresDB, *gorm.DB) {
+	logger, err := log.NewLogAndSetLevel("info")
+	if err != nil {
+		logger.Fatal(err)
 	}
 
-	repoLibrary := repositories.NewRepoLibrary(db, logger)
-	repoUser := repositories.NewRepoUsers(db, logger)
-	srv := NewServer(repoLibrary, repoUser, logger, cfg)
+	cfg, err := config.NewConfig(logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
 
-	srv.initializeRoutes()
-	logger.Infof("Listening HTTP service on %s port", cfg.AppPort)
-	err = http.ListenAndServe(fmt.Sprintf(":%s", cfg.AppPort), srv)
+	if err = log.SetLevel(logger, cfg.Postgres.LogLevel); err != nil {
+		logger.Fatal(err)
+	}
+
+	psglDB := database.NewPostgresDB()
+	db, err := psglDB.SetupDatabase(context.Background(), cfg, logger)
 	if err != nil {
 		logger.Fatal(err)
 	}
+
+	return logger, cfg, psglDB, db
+}
+
+// seedLibrary fills an empty library from the backup, the import also builds the lexemes and the shared words.
+func seedLibrary(ctx context.Context, db *gorm.DB, logger *logrus.Logger) error {
+	repoLibrary := repositories.NewRepoLibrary(db, logger)
+	count, err := repoLibrary.CountWords(ctx)
+	if err != nil {
+		return err
+	}
+
+	if count != 0 {
+		return nil
+	}
+
+	repoBackup := repositories.NewBackUpCopyRepo("save_copy/library.json", "save_copy/library.txt", logger)
+	words, err := repoBackup.GetAllFromBackUp()
+	if err != nil {
+		return err
+	}
+
+	imported, err := repoLibrary.ImportWords(ctx, words)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Library seeded, %v words", imported)
+	return nil
+}
+
+// grantAdmins makes admins of the ADMIN_EMAILS users. An email which isn't registered yet is promoted
+// on a later start whoever signs it up, the emails are never checked, so list the registered ones only.
+func grantAdmins(ctx context.Context, repoUser repositories.RepoUsers, adminEmails []string, logger *logrus.Logger) error {
+	emails := make([]string, 0, len(adminEmails))
+	for _, email := range adminEmails {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			emails = append(emails, email)
+		}
+	}
+
+	if len(emails) == 0 {
+		return nil
+	}
+
+	granted, err := repoUser.GrantAdmins(ctx, emails)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("ADMIN_EMAILS applied, %v users promoted to admin", granted)
+	return nil
 }
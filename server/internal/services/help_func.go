@@ -1,15 +1,32 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"math"
 	"server/internal/apperrors"
+	"server/internal/domain/models"
+	"server/internal/metrics"
+	"server/internal/repositories"
 	"strconv"
+	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
+	"github.com/agnivade/levenshtein"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// dummyPasswordHash has the cost of hashPassword, a login with an unknown email is checked against it
+// so it takes as long as one with a wrong password.
+const dummyPasswordHash = "$2a$14$sds7GRUx2xXOx4WutTL0DOdcP3ZFeree0uorie7tlI3wAFYAZdaIC"
+
 func hashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
 	if err != nil {
@@ -35,6 +52,7 @@ func claimJWTToken(role string, id string, expiresAt string, sekretKey []byte) (
 	claims := jwt.MapClaims{
 		"role": role,
 		"id":   id,
+		"jti":  uuid.New().String(),
 		"exp":  time.Now().Add(t).Unix(),
 	}
 
@@ -48,6 +66,20 @@ func claimJWTToken(role string, id string, expiresAt string, sekretKey []byte) (
 	return signedToken, nil
 }
 
+func generateRefreshToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", apperrors.NewRefreshTokenErr.AppendMessage(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func capitalizeFirstRune(line string) string {
 	runes := []rune(line)
 	for i, r := range runes {
@@ -59,12 +91,225 @@ func capitalizeFirstRune(line string) string {
 	return string(runes)
 }
 
-func isCyrillic(s string) bool {
+// detectLanguages guesses the language by script and alphabet, the most likely language goes first.
+func detectLanguages(s string) []string {
+	var cyrillic, latin bool
 	for _, r := range s {
 		if unicode.Is(unicode.Cyrillic, r) {
-			return true
+			cyrillic = true
+		}
+
+		if unicode.Is(unicode.Latin, r) {
+			latin = true
+		}
+	}
+
+	lower := strings.ToLower(s)
+	switch {
+	case cyrillic && strings.ContainsAny(lower, "іїєґ"):
+		return []string{models.LanguageUkrainian}
+	case cyrillic && strings.ContainsAny(lower, "ыэъё"):
+		return []string{models.LanguageRussian}
+	case cyrillic:
+		return []string{models.LanguageRussian, models.LanguageUkrainian}
+	case latin && strings.ContainsAny(lower, "äöüß"):
+		return []string{models.LanguageGerman}
+	case latin && strings.ContainsAny(lower, "ñáéíóú¿¡"):
+		return []string{models.LanguageSpanish}
+	case latin:
+		return []string{models.LanguageEnglish, models.LanguageGerman, models.LanguageSpanish}
+	}
+
+	return nil
+}
+
+// defaultTargetLanguage keeps the en <-> ru direction of the old API when the target isn't given.
+func defaultTargetLanguage(from string) string {
+	if from == models.LanguageEnglish {
+		return models.LanguageRussian
+	}
+
+	return models.LanguageEnglish
+}
+
+// languagePairOrDefault falls back to the ru -> en pair every user starts with.
+func languagePairOrDefault(from string, to string) (string, string, error) {
+	if from == "" && to == "" {
+		return models.LanguageRussian, models.LanguageEnglish, nil
+	}
+
+	if !models.IsLanguage(from) || !models.IsLanguage(to) || from == to {
+		return "", "", apperrors.LanguagePairErr.AppendMessage(from, to)
+	}
+
+	return from, to, nil
+}
+
+// chosenLanguagePair scopes a request without a pair to the pair the user has chosen.
+func chosenLanguagePair(ctx context.Context, repoUser repositories.RepoUsers, userID *uuid.UUID, from string, to string) (string, string, error) {
+	if from != "" || to != "" {
+		return languagePairOrDefault(from, to)
+	}
+
+	user, err := repoUser.GetUserById(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if user == nil || user.LanguageFrom == "" || user.LanguageTo == "" {
+		return languagePairOrDefault(from, to)
+	}
+
+	return languagePairOrDefault(user.LanguageFrom, user.LanguageTo)
+}
+
+// Search scores, every tier stays below the previous one.
+const (
+	scoreExact     = 1.0
+	scorePrefix    = 0.75
+	scoreSubstring = 0.5
+	minSimilarity  = 0.5
+)
+
+// scoreMatch ranks the text against the query, each sense of a comma separated text is tried and the best one wins.
+func scoreMatch(query string, text string) float64 {
+	query = strings.ToLower(strings.TrimSpace(query))
+	best := 0.0
+	for _, sense := range strings.Split(strings.ToLower(text), ",") {
+		sense = strings.TrimSpace(sense)
+		if sense == "" || query == "" {
+			continue
+		}
+
+		if score := scoreSense(query, sense); score > best {
+			best = score
+		}
+	}
+
+	return best
+}
+
+func scoreSense(query string, sense string) float64 {
+	queryLen := float64(utf8.RuneCountInString(query))
+	senseLen := float64(utf8.RuneCountInString(sense))
+	switch {
+	case sense == query:
+		return scoreExact
+	case strings.HasPrefix(sense, query):
+		return scorePrefix + (scoreExact-scorePrefix)*queryLen/senseLen
+	case strings.Contains(sense, query):
+		return scoreSubstring + (scorePrefix-scoreSubstring)*queryLen/senseLen
+	}
+
+	distance := float64(levenshtein.ComputeDistance(query, sense))
+	similarity := 1 - distance/math.Max(queryLen, senseLen)
+	if similarity < minSimilarity {
+		return 0
+	}
+
+	return scoreSubstring * similarity
+}
+
+// encodeWordsCursor keeps the order keys of the last word of a page.
+func encodeWordsCursor(word *models.Word) string {
+	cursor := word.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + word.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor))
+}
+
+func decodeWordsCursor(cursor string) (time.Time, *uuid.UUID, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, nil, apperrors.WordsCursorErr.AppendMessage(err)
+	}
+
+	createdAt, id, ok := strings.Cut(string(decoded), "|")
+	if !ok {
+		return time.Time{}, nil, apperrors.WordsCursorErr.AppendMessage("malformed cursor")
+	}
+
+	afterCreatedAt, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, nil, apperrors.WordsCursorErr.AppendMessage(err)
+	}
+
+	afterID, err := uuid.Parse(id)
+	if err != nil {
+		return time.Time{}, nil, apperrors.WordsCursorErr.AppendMessage(err)
+	}
+
+	return afterCreatedAt, &afterID, nil
+}
+
+// clientTime takes the time a client reports, a zero or future time becomes now.
+func clientTime(reported time.Time) time.Time {
+	now := time.Now()
+	if reported.IsZero() || reported.After(now.Add(maxClockSkew)) {
+		return now
+	}
+
+	return reported
+}
+
+// streaks counts the days in a row with answers, days is the latest first.
+// The current streak is still alive when the last answer was yesterday.
+func streaks(days []time.Time, now time.Time) (int, int) {
+	longest, run := 0, 0
+	for i, day := range days {
+		run++
+		if i > 0 && !calendarDay(days[i-1]).AddDate(0, 0, -1).Equal(calendarDay(day)) {
+			run = 1
 		}
+
+		if run > longest {
+			longest = run
+		}
+	}
+
+	today := calendarDay(now)
+	if len(days) == 0 || calendarDay(days[0]).Before(today.AddDate(0, 0, -1)) {
+		return 0, longest
+	}
+
+	current := 1
+	for i := 1; i < len(days) && calendarDay(days[i-1]).AddDate(0, 0, -1).Equal(calendarDay(days[i])); i++ {
+		current++
+	}
+
+	return current, longest
+}
+
+func calendarDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// verdict labels a graded answer for the metrics.
+func verdict(correct bool, typo bool) string {
+	switch {
+	case typo:
+		return "typo"
+	case correct:
+		return "right"
+	}
+
+	return "wrong"
+}
+
+// countWordOperation counts an applied change of a word list in the metrics.
+func countWordOperation(op *models.WordOperation) {
+	switch {
+	case op.Status != models.WordOperationApplied:
+	case op.Action == models.WordActionMoveToLearned:
+		metrics.WordsMovedToLearned.Inc()
+	case op.Action == models.WordActionAddToLearn:
+		metrics.WordsAddedToLearn.Inc()
+	}
+}
+
+// ratio rounds part/total to three decimals, 0 when total is 0.
+func ratio(part int64, total int64) float64 {
+	if total == 0 {
+		return 0
 	}
 
-	return false
+	return math.Round(float64(part)/float64(total)*1000) / 1000
 }
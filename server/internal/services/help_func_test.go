@@ -0,0 +1,72 @@
+package services
+
+import (
+	"encoding/base64"
+	"math"
+	"server/internal/domain/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestScoreMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		text  string
+		want  float64
+	}{
+		{name: "exact ignores case", query: "House", text: "house", want: scoreExact},
+		{name: "prefix", query: "hou", text: "house", want: 0.9},
+		{name: "substring", query: "ous", text: "house", want: 0.65},
+		{name: "best sense wins", query: "недостаток", text: "Отсутствие, недостаток, неявка", want: scoreExact},
+		{name: "typo", query: "hause", text: "house", want: 0.4},
+		{name: "typo in one sense", query: "недостатак", text: "Отсутствие, недостаток, неявка", want: 0.45},
+		{name: "unrelated", query: "cat", text: "house", want: 0},
+		{name: "empty query", query: " ", text: "house", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scoreMatch(tt.query, tt.text); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("scoreMatch(%q, %q) = %v, want %v", tt.query, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordsCursor(t *testing.T) {
+	id := uuid.MustParse("6f1c1f3e-2a4b-4c6d-8e9f-0a1b2c3d4e5f")
+	createdAt := time.Date(2024, time.March, 1, 12, 30, 15, 123456789, time.FixedZone("MSK", 3*60*60))
+	word := &models.Word{ID: &id}
+	word.CreatedAt = createdAt
+
+	gotCreatedAt, gotID, err := decodeWordsCursor(encodeWordsCursor(word))
+	if err != nil {
+		t.Fatalf("decodeWordsCursor of an encoded cursor: %v", err)
+	}
+
+	if !gotCreatedAt.Equal(createdAt) || *gotID != id {
+		t.Errorf("decodeWordsCursor = %v, %v, want %v, %v", gotCreatedAt, gotID, createdAt, id)
+	}
+
+	encode := func(cursor string) string { return base64.RawURLEncoding.EncodeToString([]byte(cursor)) }
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "%%%"},
+		{name: "no separator", cursor: encode("2024-03-01T12:30:15Z")},
+		{name: "bad time", cursor: encode("yesterday|" + id.String())},
+		{name: "bad id", cursor: encode("2024-03-01T12:30:15Z|42")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeWordsCursor(tt.cursor); err == nil {
+				t.Errorf("decodeWordsCursor(%q) has no error", tt.cursor)
+			}
+		})
+	}
+}
@@ -2,64 +2,316 @@ package services
 
 import (
 	"context"
+	"math"
 	"server/internal/apperrors"
+	"server/internal/domain/mappers"
 	"server/internal/domain/models"
 	"server/internal/domain/requests"
+	"server/internal/domain/responses"
+	"server/internal/metrics"
 	"server/internal/repositories"
+	"sort"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
 
 type LibraryService struct {
 	repoLibrary repositories.RepoLibrary
+	repoLexemes repositories.RepoLexemes
 	log         *logrus.Logger
 }
 
-func NewLibraryService(repoLibrary repositories.RepoLibrary, log *logrus.Logger) *LibraryService {
-	return &LibraryService{repoLibrary: repoLibrary, log: log}
+func NewLibraryService(repoLibrary repositories.RepoLibrary, repoLexemes repositories.RepoLexemes, log *logrus.Logger) *LibraryService {
+	return &LibraryService{repoLibrary: repoLibrary, repoLexemes: repoLexemes, log: log}
 }
 
-func (ls *LibraryService) GetTranslationByWord(ctx context.Context, translReq *requests.TranslationRequest) ([]*models.Library, error) {
-	capitalizedWord := capitalizeFirstRune(translReq.Word)
-	if isCyrillic(capitalizedWord) {
-		words, err := ls.repoLibrary.GetTranslationRus(capitalizedWord)
+func (ls *LibraryService) GetTranslationByWord(ctx context.Context, translReq *requests.TranslationRequest) ([]*models.Translation, error) {
+	if (translReq.From != "" && !models.IsLanguage(translReq.From)) || (translReq.To != "" && !models.IsLanguage(translReq.To)) {
+		appErr := apperrors.GetTranslationByWordErr.AppendMessage("unsupported language", translReq.From, translReq.To)
+		ls.log.Error(appErr)
+		return nil, appErr
+	}
+
+	word := strings.TrimSpace(translReq.Word)
+	languages := []string{translReq.From}
+	if translReq.From == "" {
+		languages = detectLanguages(word)
+	}
+
+	if len(languages) == 0 {
+		appErr := apperrors.GetTranslationByWordErr.AppendMessage("can't detect the language, set from")
+		ls.log.Error(appErr)
+		return nil, appErr
+	}
+
+	for _, from := range languages {
+		words, err := ls.repoLexemes.GetTranslations(ctx, from, word, ls.targetLanguage(from, translReq.To))
 		if err != nil {
 			ls.log.Error(err)
 			return nil, err
 		}
 
-		if len(words) == 0 {
-			words, err = ls.repoLibrary.GetTranslationRusLike(capitalizedWord)
-			if err != nil {
-				ls.log.Error(err)
-				return nil, err
-			}
+		if len(words) != 0 {
+			metrics.TranslationsServed.Add(float64(len(words)))
+			return words, nil
+		}
+	}
 
+	for _, from := range languages {
+		words, err := ls.repoLexemes.GetTranslationsLike(ctx, from, word, ls.targetLanguage(from, translReq.To))
+		if err != nil {
+			ls.log.Error(err)
+			return nil, err
 		}
 
-		return words, nil
+		if len(words) != 0 {
+			metrics.TranslationsServed.Add(float64(len(words)))
+			return words, nil
+		}
+	}
+
+	return []*models.Translation{}, nil
+}
+
+const (
+	searchLimit       = 20
+	searchMaxLimit    = 100
+	searchCandidates  = 200
+	searchSuggestions = 5
+	defaultPhrases    = 10
+)
+
+type scoredTranslation struct {
+	translation *models.Translation
+	score       float64
+}
+
+// SearchTranslations ranks by exact match, then prefix, then substring, then edit distance.
+// Suggestions are given only when nothing matches exactly.
+func (ls *LibraryService) SearchTranslations(ctx context.Context, searchReq *requests.SearchTranslationRequest) (*responses.SearchTranslResponse, error) {
+	if (searchReq.From != "" && !models.IsLanguage(searchReq.From)) || (searchReq.To != "" && !models.IsLanguage(searchReq.To)) {
+		appErr := apperrors.SearchTranslationsErr.AppendMessage("unsupported language", searchReq.From, searchReq.To)
+		ls.log.Error(appErr)
+		return nil, appErr
+	}
+
+	word := strings.TrimSpace(searchReq.Word)
+	if word == "" {
+		appErr := apperrors.SearchTranslationsErr.AppendMessage("word is required")
+		ls.log.Error(appErr)
+		return nil, appErr
 	}
 
-	if !isCyrillic(capitalizedWord) {
-		words, err := ls.repoLibrary.GetTranslationEngl(capitalizedWord)
+	languages := []string{searchReq.From}
+	if searchReq.From == "" {
+		languages = detectLanguages(word)
+	}
+
+	limit := searchReq.Limit
+	if limit <= 0 {
+		limit = searchLimit
+	}
+
+	if limit > searchMaxLimit {
+		limit = searchMaxLimit
+	}
+
+	ranked := []*scoredTranslation{}
+	for _, from := range languages {
+		candidates, err := ls.repoLexemes.SearchCandidates(ctx, from, word, ls.targetLanguage(from, searchReq.To), searchCandidates)
 		if err != nil {
 			ls.log.Error(err)
 			return nil, err
 		}
 
-		if len(words) == 0 {
-			words, err = ls.repoLibrary.GetTranslationEnglLike(capitalizedWord)
-			if err != nil {
-				ls.log.Error(err)
-				return nil, err
+		for _, candidate := range candidates {
+			if candidate.Source == nil || candidate.Target == nil {
+				continue
 			}
 
+			if score := scoreMatch(word, candidate.Source.Text); score > 0 {
+				ranked = append(ranked, &scoredTranslation{translation: candidate, score: score})
+			}
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
 		}
 
-		return words, nil
+		return ranked[i].translation.Source.Text < ranked[j].translation.Source.Text
+	})
+
+	searchResp := &responses.SearchTranslResponse{Results: []*responses.ScoredTranslResponse{}, DidYouMean: []string{}}
+	for i, scored := range ranked {
+		if i == limit {
+			break
+		}
+
+		searchResp.Results = append(searchResp.Results, &responses.ScoredTranslResponse{
+			GetTranslResponse: *mappers.MapTranslationToGetTranslResponse(scored.translation),
+			Score:             math.Round(scored.score*1000) / 1000,
+		})
+	}
+
+	metrics.TranslationsServed.Add(float64(len(searchResp.Results)))
+	if len(ranked) != 0 && ranked[0].score == scoreExact {
+		return searchResp, nil
+	}
+
+	seen := map[string]bool{}
+	for _, scored := range ranked {
+		if len(searchResp.DidYouMean) == searchSuggestions {
+			break
+		}
+
+		text := scored.translation.Source.Text
+		if !seen[text] {
+			seen[text] = true
+			searchResp.DidYouMean = append(searchResp.DidYouMean, text)
+		}
+	}
+
+	return searchResp, nil
+}
+
+func (ls *LibraryService) targetLanguage(from string, to string) string {
+	if to != "" {
+		return to
+	}
+
+	return defaultTargetLanguage(from)
+}
+
+func (ls *LibraryService) CreateTranslation(ctx context.Context, translReq *requests.CreateTranslationRequest) error {
+	if !models.IsLanguage(translReq.From) || !models.IsLanguage(translReq.To) || translReq.From == translReq.To {
+		appErr := apperrors.CreateTranslationServiceErr.AppendMessage("unsupported language pair", translReq.From, translReq.To)
+		ls.log.Error(appErr)
+		return appErr
+	}
+
+	if translReq.Word == "" || translReq.Translation == "" {
+		appErr := apperrors.CreateTranslationServiceErr.AppendMessage("word and translation are required")
+		ls.log.Error(appErr)
+		return appErr
+	}
+
+	source, target := mappers.MapCreateTranslationReqToLexemes(translReq)
+	err := ls.repoLexemes.CreateTranslation(ctx, source, target)
+	if err != nil {
+		ls.log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (ls *LibraryService) GetWordByID(ctx context.Context, id int) (*responses.LibraryWordResp, error) {
+	word, err := ls.repoLibrary.GetWordByID(ctx, id)
+	if err != nil {
+		ls.log.Error(err)
+		return nil, err
+	}
+
+	return mappers.MapLibraryToLibraryWordResp(word), nil
+}
+
+// GetPhrases picks library phrases to type, only the Russian text is sent.
+func (ls *LibraryService) GetPhrases(ctx context.Context, phrasesReq *requests.PhrasesRequest) ([]*responses.PhrasePromptResp, error) {
+	limit := phrasesReq.Limit
+	if limit == 0 {
+		limit = defaultPhrases
+	}
+
+	phrases, err := ls.repoLibrary.GetRandomPhrases(ctx, phrasesReq.Theme, limit)
+	if err != nil {
+		ls.log.Error(err)
+		return nil, err
+	}
+
+	phrasesResp := []*responses.PhrasePromptResp{}
+	for _, phrase := range phrases {
+		phrasesResp = append(phrasesResp, &responses.PhrasePromptResp{ID: phrase.ID, Prompt: phrase.Russian})
+	}
+
+	return phrasesResp, nil
+}
+
+func (ls *LibraryService) CheckPhrase(ctx context.Context, checkReq *requests.CheckPhraseRequest) (*responses.PhraseVerdictResp, error) {
+	phrase, err := ls.repoLibrary.GetPhraseByID(ctx, checkReq.PhraseID)
+	if err != nil {
+		ls.log.Error(err)
+		return nil, err
+	}
+
+	correct, typo := checkPhrase(checkReq.Answer, phrase.English)
+	metrics.QuizAnswersChecked.WithLabelValues(verdict(correct, typo)).Inc()
+	return &responses.PhraseVerdictResp{
+		PhraseID: phrase.ID,
+		Correct:  correct,
+		Typo:     typo,
+		Answer:   checkReq.Answer,
+		Expected: phrase.English,
+	}, nil
+}
+
+func (ls *LibraryService) CreateWord(ctx context.Context, wordReq *requests.LibraryWordRequest) (*responses.LibraryWordResp, error) {
+	if wordReq.English == "" || wordReq.Russian == "" {
+		appErr := apperrors.SaveLibraryWordErr.AppendMessage("english and russian are required")
+		ls.log.Error(appErr)
+		return nil, appErr
+	}
+
+	word := mappers.MapLibraryWordReqToLibrary(wordReq)
+	word.English = capitalizeFirstRune(word.English)
+	word.Russian = capitalizeFirstRune(word.Russian)
+	err := ls.repoLibrary.CreateWord(ctx, word)
+	if err != nil {
+		ls.log.Error(err)
+		return nil, err
+	}
+
+	return mappers.MapLibraryToLibraryWordResp(word), nil
+}
+
+func (ls *LibraryService) UpdateWord(ctx context.Context, id int, wordReq *requests.LibraryWordRequest) (*responses.LibraryWordResp, error) {
+	if wordReq.English == "" || wordReq.Russian == "" {
+		appErr := apperrors.SaveLibraryWordErr.AppendMessage("english and russian are required")
+		ls.log.Error(appErr)
+		return nil, appErr
+	}
+
+	word := mappers.MapLibraryWordReqToLibrary(wordReq)
+	word.ID = id
+	word.English = capitalizeFirstRune(word.English)
+	word.Russian = capitalizeFirstRune(word.Russian)
+	err := ls.repoLibrary.UpdateWord(ctx, word)
+	if err != nil {
+		ls.log.Error(err)
+		return nil, err
+	}
+
+	return ls.GetWordByID(ctx, id)
+}
+
+func (ls *LibraryService) DeleteWord(ctx context.Context, id int) error {
+	err := ls.repoLibrary.DeleteWord(ctx, id)
+	if err != nil {
+		ls.log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (ls *LibraryService) ImportWords(ctx context.Context, library []*models.Library) (*responses.ImportLibraryResponse, error) {
+	imported, err := ls.repoLibrary.ImportWords(ctx, library)
+	if err != nil {
+		ls.log.Error(err)
+		return nil, err
 	}
 
-	appErr := apperrors.GetTranslationByWordErr.AppendMessage("this word isn't rus or english, try to change your language")
-	ls.log.Error(appErr)
-	return nil, appErr
+	return &responses.ImportLibraryResponse{Imported: imported}, nil
 }
@@ -0,0 +1,477 @@
+package services
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"server/internal/apperrors"
+	"server/internal/domain/mappers"
+	"server/internal/domain/models"
+	"server/internal/domain/requests"
+	"server/internal/domain/responses"
+	"server/internal/metrics"
+	"server/internal/repositories"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	statsDays         = 30
+	statsWeeks        = 12
+	statsHardestWords = 10
+	statsDateLayout   = "2006-01-02"
+	// maxClockSkew is how far in the future a client clock may be.
+	maxClockSkew = 5 * time.Minute
+)
+
+type QuizService struct {
+	repoQuiz  repositories.RepoQuiz
+	repoUsers repositories.RepoUsers
+	log       *logrus.Logger
+}
+
+func NewQuizService(repoQuiz repositories.RepoQuiz, repoUsers repositories.RepoUsers, log *logrus.Logger) *QuizService {
+	return &QuizService{repoQuiz: repoQuiz, repoUsers: repoUsers, log: log}
+}
+
+func (qs *QuizService) StartSession(ctx context.Context, startReq *requests.StartQuizSessionRequest) (*responses.QuizSessionResp, error) {
+	userID, err := uuid.Parse(startReq.UserID)
+	if err != nil {
+		appErr := apperrors.StartQuizSessionErr.AppendMessage(err)
+		qs.log.Error(appErr)
+		return nil, appErr
+	}
+
+	sessionID := uuid.New()
+	session := &models.QuizSession{
+		ID:        &sessionID,
+		UserID:    &userID,
+		Mode:      startReq.Mode,
+		StartedAt: clientTime(startReq.StartedAt),
+	}
+	if startReq.Size != 0 {
+		session.Choices = startReq.Choices
+		session.Direction = startReq.Direction
+		if session.Direction == "" {
+			session.Direction = models.QuizDirectionForward
+		}
+
+		session.Items, err = qs.pickItems(ctx, session, startReq)
+		if err != nil {
+			appErr := apperrors.StartQuizSessionErr.AppendMessage(err)
+			qs.log.Error(appErr)
+			return nil, appErr
+		}
+	}
+
+	err = qs.repoQuiz.CreateSession(ctx, session)
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	return mappers.MapQuizSessionToQuizSessionResp(session), nil
+}
+
+// pickItems asks the first words of the test or the learn list, the mix direction flips a coin for every word.
+func (qs *QuizService) pickItems(ctx context.Context, session *models.QuizSession, startReq *requests.StartQuizSessionRequest) ([]*models.QuizItem, error) {
+	from, to, err := chosenLanguagePair(ctx, qs.repoUsers, session.UserID, startReq.From, startReq.To)
+	if err != nil {
+		return nil, err
+	}
+
+	getWords := qs.repoUsers.GetWordsByIDAndLimit
+	if session.Mode == models.QuizModeLearn {
+		getWords = qs.repoUsers.GetLearnByIDAndLimit
+	}
+
+	words, _, err := getWords(ctx, session.UserID, &repositories.WordsFilter{From: from, To: to, Limit: startReq.Size})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(words) == 0 {
+		return nil, apperrors.QuizNoWordsErr.AppendMessage(session.Mode, from, to)
+	}
+
+	items := make([]*models.QuizItem, 0, len(words))
+	for i, word := range words {
+		reverse := session.Direction == models.QuizDirectionReverse ||
+			session.Direction == models.QuizDirectionMix && rand.Intn(2) == 1
+		items = append(items, &models.QuizItem{SessionID: session.ID, Position: i + 1, WordID: word.ID, Reverse: reverse})
+	}
+
+	return items, nil
+}
+
+// NextPrompt returns the next question of a session the server grades, the same one until it is answered.
+func (qs *QuizService) NextPrompt(ctx context.Context, nextReq *requests.NextQuizPromptRequest) (*responses.QuizPromptResp, error) {
+	session, err := qs.openSession(ctx, nextReq.UserID, nextReq.SessionID)
+	if err != nil {
+		appErr := apperrors.NextQuizPromptErr.AppendMessage(err)
+		qs.log.Error(appErr)
+		return nil, appErr
+	}
+
+	item, err := qs.repoQuiz.GetNextItem(ctx, session.ID)
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	counts, err := qs.repoQuiz.CountItems(ctx, session.ID)
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	if item == nil {
+		return &responses.QuizPromptResp{SessionID: session.ID.String(), Done: true, Total: counts.Items}, nil
+	}
+
+	err = qs.repoQuiz.MarkItemPrompted(ctx, item)
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	promptResp := mappers.MapQuizItemToQuizPromptResp(item)
+	promptResp.Remaining = counts.Remaining
+	promptResp.Total = counts.Items
+	if session.Choices != 0 {
+		promptResp.Choices, err = qs.choices(ctx, session, item)
+		if err != nil {
+			qs.log.Error(err)
+			return nil, err
+		}
+	}
+
+	return promptResp, nil
+}
+
+// choices mixes the answer with the distractors, the order depends on the item only so a repeated prompt looks the same.
+func (qs *QuizService) choices(ctx context.Context, session *models.QuizSession, item *models.QuizItem) ([]string, error) {
+	seed := strconv.FormatUint(uint64(item.ID), 10)
+	distractors, err := qs.repoQuiz.GetDistractors(ctx, item.Word, item.Reverse, seed, session.Choices-1)
+	if err != nil {
+		return nil, err
+	}
+
+	answer := item.Word.English
+	if item.Reverse {
+		answer = item.Word.Russian
+	}
+
+	choices := append(distractors, answer)
+	shuffle := rand.New(rand.NewSource(int64(item.ID)))
+	shuffle.Shuffle(len(choices), func(i, j int) {
+		choices[i], choices[j] = choices[j], choices[i]
+	})
+
+	return choices, nil
+}
+
+// CheckAnswer grades the answer to the current prompt, records it and moves the word the way the mode does.
+func (qs *QuizService) CheckAnswer(ctx context.Context, checkReq *requests.CheckQuizAnswerRequest) (*responses.QuizVerdictResp, error) {
+	session, err := qs.openSession(ctx, checkReq.UserID, checkReq.SessionID)
+	if err != nil {
+		appErr := apperrors.CheckQuizAnswerErr.AppendMessage(err)
+		qs.log.Error(appErr)
+		return nil, appErr
+	}
+
+	item, err := qs.repoQuiz.GetNextItem(ctx, session.ID)
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	if item == nil || item.Position != checkReq.Position {
+		appErr := apperrors.CheckQuizAnswerErr.AppendMessage(&apperrors.QuizPromptMismatchErr)
+		qs.log.Error(appErr)
+		return nil, appErr
+	}
+
+	accepted, err := qs.repoQuiz.GetAcceptedAnswers(ctx, item.Word, item.Reverse)
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	expected := item.Word.English
+	if item.Reverse {
+		expected = item.Word.Russian
+	}
+
+	correct, typo := checkAnswer(checkReq.Answer, append(accepted, expected))
+	now := time.Now()
+	answer := &models.QuizAnswer{
+		SessionID:  session.ID,
+		UserID:     session.UserID,
+		WordID:     item.WordID,
+		Correct:    correct,
+		Typo:       typo,
+		AnsweredAt: now,
+	}
+	if item.PromptedAt != nil {
+		answer.LatencyMs = now.Sub(*item.PromptedAt).Milliseconds()
+	}
+
+	op := progressOperation(session, item, correct)
+	err = qs.repoQuiz.AnswerItem(ctx, item, answer, !correct && session.Mode == models.QuizModeLearn, op, func(review *models.Review) {
+		startReview(review, now)
+	})
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	metrics.QuizAnswersChecked.WithLabelValues(verdict(correct, typo)).Inc()
+	if op != nil {
+		countWordOperation(op)
+	}
+
+	counts, err := qs.repoQuiz.CountItems(ctx, session.ID)
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	return &responses.QuizVerdictResp{
+		Position:  item.Position,
+		WordID:    item.WordID.String(),
+		Correct:   correct,
+		Typo:      typo,
+		Answer:    checkReq.Answer,
+		Expected:  expected,
+		Remaining: counts.Remaining,
+	}, nil
+}
+
+// progressOperation is the change an answer makes to the word list, the one the clients made after grading:
+// a right test answer learns the word and a wrong one puts it in the learn list, a right learn answer takes it out of the list.
+func progressOperation(session *models.QuizSession, item *models.QuizItem, correct bool) *models.WordOperation {
+	switch {
+	case session.Mode == models.QuizModeTest && correct:
+		return &models.WordOperation{WordID: item.WordID, Action: models.WordActionMoveToLearned}
+	case session.Mode == models.QuizModeTest:
+		return &models.WordOperation{WordID: item.WordID, Action: models.WordActionAddToLearn}
+	case correct:
+		return &models.WordOperation{WordID: item.WordID, Action: models.WordActionDeleteLearn}
+	}
+
+	return nil
+}
+
+// RecordAnswer keeps an answer graded by the client, it is for the sessions without items which are played offline.
+// The answers of a session with items are graded by CheckAnswer only.
+func (qs *QuizService) RecordAnswer(ctx context.Context, answerReq *requests.QuizAnswerRequest) error {
+	session, err := qs.openSession(ctx, answerReq.UserID, answerReq.SessionID)
+	if err != nil {
+		appErr := apperrors.QuizAnswerErr.AppendMessage(err)
+		qs.log.Error(appErr)
+		return appErr
+	}
+
+	itemCounts, err := qs.repoQuiz.CountItems(ctx, session.ID)
+	if err != nil {
+		qs.log.Error(err)
+		return err
+	}
+
+	if itemCounts.Items != 0 {
+		appErr := apperrors.QuizAnswerErr.AppendMessage(apperrors.QuizSessionGradedErr.AppendMessage(answerReq.SessionID))
+		qs.log.Error(appErr)
+		return appErr
+	}
+
+	wordID, err := uuid.Parse(answerReq.WordID)
+	if err != nil {
+		appErr := apperrors.QuizAnswerErr.AppendMessage(err)
+		qs.log.Error(appErr)
+		return appErr
+	}
+
+	answer := &models.QuizAnswer{
+		SessionID:  session.ID,
+		UserID:     session.UserID,
+		WordID:     &wordID,
+		Correct:    answerReq.Correct,
+		Typo:       answerReq.Typo,
+		LatencyMs:  answerReq.LatencyMs,
+		AnsweredAt: clientTime(answerReq.AnsweredAt),
+	}
+	err = qs.repoQuiz.CreateAnswer(ctx, answer)
+	if err != nil {
+		qs.log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (qs *QuizService) FinishSession(ctx context.Context, finishReq *requests.FinishQuizSessionRequest) (*responses.QuizSummaryResp, error) {
+	session, err := qs.openSession(ctx, finishReq.UserID, finishReq.SessionID)
+	if err != nil {
+		appErr := apperrors.FinishQuizSessionErr.AppendMessage(err)
+		qs.log.Error(appErr)
+		return nil, appErr
+	}
+
+	finishedAt := clientTime(finishReq.FinishedAt)
+	session.FinishedAt = &finishedAt
+	err = qs.repoQuiz.SaveSession(ctx, session)
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	counts, err := qs.repoQuiz.CountAnswers(ctx, session.ID)
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	return &responses.QuizSummaryResp{
+		QuizSessionResp: *mappers.MapQuizSessionToQuizSessionResp(session),
+		Right:           counts.Correct,
+		Wrong:           counts.Answers - counts.Correct,
+		Typos:           counts.Typos,
+		DurationSeconds: math.Round(finishedAt.Sub(session.StartedAt).Seconds()),
+	}, nil
+}
+
+// openSession returns the unfinished session of the user, a session of another user is reported as not found.
+func (qs *QuizService) openSession(ctx context.Context, userID string, sessionID string) (*models.QuizSession, error) {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return nil, apperrors.BadRequestErr.AppendMessage(err)
+	}
+
+	session, err := qs.repoQuiz.GetSession(ctx, &id)
+	if err != nil {
+		return nil, err
+	}
+
+	if session == nil || session.UserID == nil || session.UserID.String() != userID {
+		return nil, apperrors.QuizSessionNotFoundErr.AppendMessage(sessionID)
+	}
+
+	if session.FinishedAt != nil {
+		return nil, apperrors.QuizSessionFinishedErr.AppendMessage(sessionID)
+	}
+
+	return session, nil
+}
+
+func (qs *QuizService) GetStats(ctx context.Context, statsReq *requests.StatsRequest) (*responses.StatsResp, error) {
+	userID, err := uuid.Parse(statsReq.UserID)
+	if err != nil {
+		appErr := apperrors.GetStatsServiceErr.AppendMessage(err)
+		qs.log.Error(appErr)
+		return nil, appErr
+	}
+
+	days := statsDays
+	if statsReq.Days != "" {
+		days, err = strconv.Atoi(statsReq.Days)
+		if err != nil {
+			appErr := apperrors.GetStatsServiceErr.AppendMessage(err)
+			qs.log.Error(appErr)
+			return nil, appErr
+		}
+	}
+
+	from, to, err := chosenLanguagePair(ctx, qs.repoUsers, &userID, statsReq.From, statsReq.To)
+	if err != nil {
+		appErr := apperrors.GetStatsServiceErr.AppendMessage(err)
+		qs.log.Error(appErr)
+		return nil, appErr
+	}
+
+	now := time.Now()
+	answerDays, err := qs.repoQuiz.GetAnswerDays(ctx, &userID)
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	daily, err := qs.repoQuiz.GetDailyStats(ctx, &userID, now.AddDate(0, 0, -days))
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	weekly, err := qs.repoQuiz.GetLearnedPerWeek(ctx, &userID, now.AddDate(0, 0, -7*statsWeeks))
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	hardest, err := qs.repoQuiz.GetHardestWords(ctx, &userID, statsHardestWords)
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	themes, err := qs.repoQuiz.GetThemeStats(ctx, &userID, from, to)
+	if err != nil {
+		qs.log.Error(err)
+		return nil, err
+	}
+
+	statsResp := &responses.StatsResp{
+		Daily:          []*responses.DailyStatsResp{},
+		LearnedPerWeek: []*responses.WeeklyStatsResp{},
+		HardestWords:   []*responses.HardWordResp{},
+		Themes:         []*responses.ThemeMasteryResp{},
+	}
+	statsResp.CurrentStreak, statsResp.LongestStreak = streaks(answerDays, now)
+
+	var correct int64
+	for _, day := range daily {
+		statsResp.Answers += day.Answers
+		correct += day.Correct
+		statsResp.Daily = append(statsResp.Daily, &responses.DailyStatsResp{
+			Date:     day.Day.Format(statsDateLayout),
+			Answers:  day.Answers,
+			Correct:  day.Correct,
+			Accuracy: ratio(day.Correct, day.Answers),
+		})
+	}
+
+	statsResp.Accuracy = ratio(correct, statsResp.Answers)
+	for _, week := range weekly {
+		statsResp.LearnedPerWeek = append(statsResp.LearnedPerWeek, &responses.WeeklyStatsResp{
+			WeekStart: week.Week.Format(statsDateLayout),
+			Learned:   week.Learned,
+		})
+	}
+
+	for _, word := range hardest {
+		statsResp.HardestWords = append(statsResp.HardestWords, &responses.HardWordResp{
+			WordID:    word.WordID,
+			English:   word.English,
+			Russian:   word.Russian,
+			Answers:   word.Answers,
+			Wrong:     word.Wrong,
+			ErrorRate: ratio(word.Wrong, word.Answers),
+		})
+	}
+
+	for _, theme := range themes {
+		statsResp.Themes = append(statsResp.Themes, &responses.ThemeMasteryResp{
+			Theme:    theme.Theme,
+			Words:    theme.Words,
+			Learned:  theme.Learned,
+			Mastery:  ratio(theme.Learned, theme.Words),
+			Answers:  theme.Answers,
+			Accuracy: ratio(theme.Correct, theme.Answers),
+		})
+	}
+
+	return statsResp, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"math"
+	"server/internal/apperrors"
+	"server/internal/domain/mappers"
+	"server/internal/domain/models"
+	"server/internal/domain/requests"
+	"server/internal/domain/responses"
+	"server/internal/metrics"
+	"server/internal/repositories"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultEaseFactor = 2.5
+	minEaseFactor     = 1.3
+	minPassQuality    = 3
+	maxQuality        = 5
+	// learnedQuality is the grade given to a word answered right in the test.
+	learnedQuality = 4
+)
+
+type ReviewService struct {
+	repoReviews repositories.RepoReviews
+	log         *logrus.Logger
+}
+
+func NewReviewService(repoReviews repositories.RepoReviews, log *logrus.Logger) *ReviewService {
+	return &ReviewService{repoReviews: repoReviews, log: log}
+}
+
+func (rs *ReviewService) GetDueWordsByUsIdAndLimit(ctx context.Context, getWordsReq *requests.GetWordsByUsIdAndLimitRequest) ([]*responses.WordResp, error) {
+	quantity := getWordsReq.Limit
+	if quantity == 0 {
+		quantity = wordsPageLimit
+	}
+
+	userId, err := uuid.Parse(getWordsReq.ID)
+	if err != nil {
+		appErr := apperrors.GetDueWordsServiceErr.AppendMessage(err)
+		rs.log.Error(appErr)
+		return nil, appErr
+	}
+
+	words, err := rs.repoReviews.GetDueWordsByIDAndLimit(ctx, &userId, time.Now(), quantity)
+	if err != nil {
+		rs.log.Error(err)
+		return nil, err
+	}
+
+	return mappers.MapWordsToWordsResp(words), nil
+}
+
+func (rs *ReviewService) AnswerReview(ctx context.Context, answerReq *requests.ReviewAnswerRequest) (*responses.ReviewResp, error) {
+	if answerReq.Quality < 0 || answerReq.Quality > maxQuality {
+		appErr := apperrors.AnswerReviewErr.AppendMessage("quality must be between 0 and 5")
+		rs.log.Error(appErr)
+		return nil, appErr
+	}
+
+	review, err := rs.schedule(ctx, answerReq.UserID, answerReq.WordID, answerReq.Quality)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.ReviewsAnswered.Inc()
+	return mappers.MapReviewToReviewResp(review), nil
+}
+
+func (rs *ReviewService) schedule(ctx context.Context, userID, wordID string, quality int) (*models.Review, error) {
+	userId, err := uuid.Parse(userID)
+	if err != nil {
+		appErr := apperrors.AnswerReviewErr.AppendMessage(err)
+		rs.log.Error(appErr)
+		return nil, appErr
+	}
+
+	wordId, err := uuid.Parse(wordID)
+	if err != nil {
+		appErr := apperrors.AnswerReviewErr.AppendMessage(err)
+		rs.log.Error(appErr)
+		return nil, appErr
+	}
+
+	review, err := rs.repoReviews.GetReview(ctx, &userId, &wordId)
+	if err != nil {
+		rs.log.Error(err)
+		return nil, err
+	}
+
+	if review == nil {
+		review = &models.Review{UserID: &userId, WordID: &wordId, EaseFactor: defaultEaseFactor}
+	}
+
+	scheduleSM2(review, quality, time.Now())
+	err = rs.repoReviews.SaveReview(ctx, review)
+	if err != nil {
+		rs.log.Error(err)
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// startReview grades a word which has just been moved to learned, a review which isn't stored yet
+// starts from the default ease factor.
+func startReview(review *models.Review, now time.Time) {
+	if review.ID == 0 {
+		review.EaseFactor = defaultEaseFactor
+	}
+
+	scheduleSM2(review, learnedQuality, now)
+}
+
+// scheduleSM2 updates the review state after an answer graded from 0 (blackout) to 5 (perfect).
+func scheduleSM2(review *models.Review, quality int, now time.Time) {
+	if quality < minPassQuality {
+		review.Repetitions = 0
+		review.Interval = 1
+	} else {
+		switch review.Repetitions {
+		case 0:
+			review.Interval = 1
+		case 1:
+			review.Interval = 6
+		default:
+			review.Interval = int(math.Round(float64(review.Interval) * review.EaseFactor))
+		}
+
+		review.Repetitions++
+	}
+
+	miss := float64(maxQuality - quality)
+	review.EaseFactor += 0.1 - miss*(0.08+miss*0.02)
+	if review.EaseFactor < minEaseFactor {
+		review.EaseFactor = minEaseFactor
+	}
+
+	review.NextReviewAt = now.AddDate(0, 0, review.Interval)
+}
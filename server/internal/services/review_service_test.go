@@ -0,0 +1,83 @@
+package services
+
+import (
+	"math"
+	"server/internal/domain/models"
+	"testing"
+	"time"
+)
+
+func TestScheduleSM2(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name            string
+		review          models.Review
+		quality         int
+		wantInterval    int
+		wantRepetitions int
+		wantEaseFactor  float64
+	}{
+		{
+			name:            "first perfect answer",
+			review:          models.Review{EaseFactor: 2.5},
+			quality:         5,
+			wantInterval:    1,
+			wantRepetitions: 1,
+			wantEaseFactor:  2.6,
+		},
+		{
+			name:            "second answer waits six days",
+			review:          models.Review{EaseFactor: 2.5, Interval: 1, Repetitions: 1},
+			quality:         4,
+			wantInterval:    6,
+			wantRepetitions: 2,
+			wantEaseFactor:  2.5,
+		},
+		{
+			name:            "later answers multiply by the ease factor",
+			review:          models.Review{EaseFactor: 2.5, Interval: 6, Repetitions: 2},
+			quality:         3,
+			wantInterval:    15,
+			wantRepetitions: 3,
+			wantEaseFactor:  2.36,
+		},
+		{
+			name:            "failed answer starts over",
+			review:          models.Review{EaseFactor: 2.5, Interval: 15, Repetitions: 3},
+			quality:         2,
+			wantInterval:    1,
+			wantRepetitions: 0,
+			wantEaseFactor:  2.18,
+		},
+		{
+			name:            "ease factor doesn't fall below the minimum",
+			review:          models.Review{EaseFactor: 1.4, Interval: 6, Repetitions: 2},
+			quality:         0,
+			wantInterval:    1,
+			wantRepetitions: 0,
+			wantEaseFactor:  minEaseFactor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := tt.review
+			scheduleSM2(&review, tt.quality, now)
+			if review.Interval != tt.wantInterval {
+				t.Errorf("Interval = %v, want %v", review.Interval, tt.wantInterval)
+			}
+
+			if review.Repetitions != tt.wantRepetitions {
+				t.Errorf("Repetitions = %v, want %v", review.Repetitions, tt.wantRepetitions)
+			}
+
+			if math.Abs(review.EaseFactor-tt.wantEaseFactor) > 1e-9 {
+				t.Errorf("EaseFactor = %v, want %v", review.EaseFactor, tt.wantEaseFactor)
+			}
+
+			if want := now.AddDate(0, 0, tt.wantInterval); !review.NextReviewAt.Equal(want) {
+				t.Errorf("NextReviewAt = %v, want %v", review.NextReviewAt, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"server/internal/apperrors"
+	"server/internal/domain/mappers"
+	"server/internal/domain/models"
+	"server/internal/domain/requests"
+	"server/internal/domain/responses"
+	"server/internal/repositories"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type TokenService struct {
+	repoUser          repositories.RepoUsers
+	repoRefreshTokens repositories.RepoRefreshTokens
+	log               *logrus.Logger
+}
+
+func NewTokenService(repoUser repositories.RepoUsers, repoRefreshTokens repositories.RepoRefreshTokens, log *logrus.Logger) *TokenService {
+	return &TokenService{repoUser: repoUser, repoRefreshTokens: repoRefreshTokens, log: log}
+}
+
+func (ts *TokenService) SignInUserWithJWT(ctx context.Context, logReq *requests.LoginRequest, secretKey string, expiresAt string, refreshExpiresAt string) (*responses.LoginResponse, error) {
+	user, err := ts.repoUser.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(logReq.Email)))
+	if err != nil {
+		ts.log.Error(err)
+		return nil, err
+	}
+
+	if user == nil || user.ID == nil {
+		checkPasswordHash(logReq.Password, dummyPasswordHash)
+		appErr := apperrors.SignInUserWithJWTErr.AppendMessage(&apperrors.InvalidCredentialsErr)
+		ts.log.Error(appErr)
+		return nil, appErr
+	}
+
+	if !checkPasswordHash(logReq.Password, user.Password) {
+		appErr := apperrors.SignInUserWithJWTErr.AppendMessage(&apperrors.InvalidCredentialsErr)
+		ts.log.Error(appErr)
+		return nil, appErr
+	}
+
+	token, err := claimJWTToken(user.Role, user.ID.String(), expiresAt, []byte(secretKey))
+	if err != nil {
+		ts.log.Error(err)
+		return nil, err
+	}
+
+	refreshToken, refreshModel, err := newRefreshToken(user, logReq.Device, refreshExpiresAt)
+	if err != nil {
+		ts.log.Error(err)
+		return nil, err
+	}
+
+	err = ts.repoRefreshTokens.CreateRefreshToken(ctx, refreshModel)
+	if err != nil {
+		ts.log.Error(err)
+		return nil, err
+	}
+
+	return mappers.MapTokenToLoginResponse(token, expiresAt, refreshToken, refreshExpiresAt), nil
+}
+
+// RefreshTokens rotates the refresh token and issues a new access token.
+// A refresh token presented twice means it has leaked, so the whole device session is revoked.
+func (ts *TokenService) RefreshTokens(ctx context.Context, refreshReq *requests.RefreshTokenRequest, secretKey string, expiresAt string, refreshExpiresAt string) (*responses.LoginResponse, error) {
+	if refreshReq.RefreshToken == "" {
+		appErr := apperrors.RefreshTokensErr.AppendMessage("refresh token is empty")
+		ts.log.Error(appErr)
+		return nil, appErr
+	}
+
+	oldToken, err := ts.repoRefreshTokens.GetRefreshTokenByHash(ctx, hashRefreshToken(refreshReq.RefreshToken))
+	if err != nil {
+		ts.log.Error(err)
+		return nil, err
+	}
+
+	if oldToken.RevokedAt != nil {
+		err = ts.repoRefreshTokens.RevokeUserDeviceTokens(ctx, oldToken.UserID, oldToken.Device)
+		if err != nil {
+			ts.log.Error(err)
+		}
+
+		appErr := apperrors.RefreshTokensErr.AppendMessage("refresh token reuse detected, session revoked")
+		ts.log.Error(appErr)
+		return nil, appErr
+	}
+
+	if time.Now().After(oldToken.ExpiresAt) {
+		appErr := apperrors.RefreshTokensErr.AppendMessage("refresh token has expired")
+		ts.log.Error(appErr)
+		return nil, appErr
+	}
+
+	user, err := ts.repoUser.GetUserById(ctx, oldToken.UserID)
+	if err != nil {
+		ts.log.Error(err)
+		return nil, err
+	}
+
+	if user == nil || user.ID == nil {
+		appErr := apperrors.RefreshTokensErr.AppendMessage("user not found")
+		ts.log.Error(appErr)
+		return nil, appErr
+	}
+
+	token, err := claimJWTToken(user.Role, user.ID.String(), expiresAt, []byte(secretKey))
+	if err != nil {
+		ts.log.Error(err)
+		return nil, err
+	}
+
+	refreshToken, refreshModel, err := newRefreshToken(user, oldToken.Device, refreshExpiresAt)
+	if err != nil {
+		ts.log.Error(err)
+		return nil, err
+	}
+
+	err = ts.repoRefreshTokens.RotateRefreshToken(ctx, oldToken, refreshModel)
+	if err != nil {
+		ts.log.Error(err)
+		return nil, err
+	}
+
+	return mappers.MapTokenToLoginResponse(token, expiresAt, refreshToken, refreshExpiresAt), nil
+}
+
+func (ts *TokenService) RevokeRefreshToken(ctx context.Context, refreshReq *requests.RefreshTokenRequest) error {
+	if refreshReq.RefreshToken == "" {
+		return nil
+	}
+
+	err := ts.repoRefreshTokens.RevokeRefreshToken(ctx, hashRefreshToken(refreshReq.RefreshToken))
+	if err != nil {
+		ts.log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func newRefreshToken(user *models.User, device string, expiresAt string) (string, *models.RefreshToken, error) {
+	expiresAtNum, err := strconv.Atoi(expiresAt)
+	if err != nil {
+		appErr := apperrors.NewRefreshTokenErr.AppendMessage(err)
+		return "", nil, appErr
+	}
+
+	token, err := generateRefreshToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	refreshModel := &models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashRefreshToken(token),
+		Device:    device,
+		ExpiresAt: time.Now().Add(time.Duration(expiresAtNum) * time.Second),
+	}
+
+	return token, refreshModel, nil
+}
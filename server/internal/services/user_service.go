@@ -7,8 +7,10 @@ import (
 	"server/internal/domain/models"
 	"server/internal/domain/requests"
 	"server/internal/domain/responses"
+	"server/internal/metrics"
 	"server/internal/repositories"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -17,11 +19,12 @@ import (
 type UserService struct {
 	repoUser    repositories.RepoUsers
 	repoLibrary repositories.RepoLibrary
+	repoLexemes repositories.RepoLexemes
 	log         *logrus.Logger
 }
 
-func NewUserService(userRepo repositories.RepoUsers, repoLibrary repositories.RepoLibrary, log *logrus.Logger) *UserService {
-	return &UserService{repoUser: userRepo, repoLibrary: repoLibrary, log: log}
+func NewUserService(userRepo repositories.RepoUsers, repoLibrary repositories.RepoLibrary, repoLexemes repositories.RepoLexemes, log *logrus.Logger) *UserService {
+	return &UserService{repoUser: userRepo, repoLibrary: repoLibrary, repoLexemes: repoLexemes, log: log}
 }
 
 func (us *UserService) CreateUser(ctx context.Context, userReq *requests.CreateUserRequest) (*responses.CreateUserResponse, error) {
@@ -47,97 +50,114 @@ func (us *UserService) CreateUser(ctx context.Context, userReq *requests.CreateU
 	}
 
 	user.ID = &userUUID
-
-	library, err := us.repoLibrary.GetAllWords()
-	if err != nil {
-		return nil, err
-	}
-
-	words := mappers.MapLibraryToWords(library)
-	user.Words = words
-	us.repoUser.UpdateUser(ctx, user)
+	metrics.UsersCreated.Inc()
 	respCreateUser := &responses.CreateUserResponse{UserId: user.ID.String()}
 	return respCreateUser, nil
 }
 
-func (us *UserService) SignInUserWithJWT(ctx context.Context, logReq *requests.LoginRequest, secretKey string, expiresAt string) (*responses.LoginResponse, error) {
-	user, err := us.repoUser.GetUserByEmail(ctx, logReq.Email)
+// wordsPageLimit is the page of a request without a limit, the requests validate the most a page may have.
+const wordsPageLimit = 20
+
+func (us *UserService) GetWordsByUsIdAndLimit(ctx context.Context, getWordsReq *requests.GetWordsByUsIdAndLimitRequest) (*responses.WordsPageResp, error) {
+	userId, filter, err := us.wordsFilter(ctx, getWordsReq)
 	if err != nil {
 		us.log.Error(err)
 		return nil, err
 	}
 
-	if !checkPasswordHash(logReq.Password, user.Password) {
-		appErr := apperrors.SignInUserWithJWTErr.AppendMessage("check password err")
-		us.log.Error(appErr)
-		return nil, appErr
-	}
-
-	token, err := claimJWTToken(user.Role, user.ID.String(), expiresAt, []byte(secretKey))
+	words, total, err := us.repoUser.GetWordsByIDAndLimit(ctx, userId, filter)
 	if err != nil {
 		us.log.Error(err)
 		return nil, err
 	}
 
-	return mappers.MapTokenToLoginResponse(token, expiresAt), nil
+	return us.wordsPage(words, total, filter.Limit-1), nil
 }
 
-func (us *UserService) GetWordsByUsIdAndLimit(ctx context.Context, getWordsReq *requests.GetWordsByUsIdAndLimitRequest) ([]*responses.WordResp, error) {
-	quantity, err := strconv.Atoi(getWordsReq.Limit)
+func (us *UserService) GetLearnByUsIdAndLimit(ctx context.Context, getWordsReq *requests.GetWordsByUsIdAndLimitRequest) (*responses.WordsPageResp, error) {
+	userId, filter, err := us.wordsFilter(ctx, getWordsReq)
 	if err != nil {
-		appErr := apperrors.GetWordsByUsIdAndLimitServiceErr.AppendMessage(err)
-		us.log.Error(appErr)
-		return nil, appErr
+		us.log.Error(err)
+		return nil, err
 	}
 
-	userId, err := uuid.Parse(getWordsReq.ID)
+	words, total, err := us.repoUser.GetLearnByIDAndLimit(ctx, userId, filter)
 	if err != nil {
-		appErr := apperrors.GetWordsByUsIdAndLimitServiceErr.AppendMessage(err)
-		us.log.Error(appErr)
-		return nil, appErr
+		us.log.Error(err)
+		return nil, err
 	}
 
-	words, err := us.repoUser.GetWordsByIDAndLimit(ctx, &userId, quantity)
+	return us.wordsPage(words, total, filter.Limit-1), nil
+}
+
+func (us *UserService) GetLearnedByUsIdAndLimit(ctx context.Context, getWordsReq *requests.GetWordsByUsIdAndLimitRequest) (*responses.WordsPageResp, error) {
+	userId, filter, err := us.wordsFilter(ctx, getWordsReq)
 	if err != nil {
 		us.log.Error(err)
 		return nil, err
 	}
 
-	wordsResp := mappers.MapWordsToWordsResp(words)
-	return wordsResp, nil
+	words, total, err := us.repoUser.GetLearnedByIDAndLimit(ctx, userId, filter)
+	if err != nil {
+		us.log.Error(err)
+		return nil, err
+	}
+
+	return us.wordsPage(words, total, filter.Limit-1), nil
 }
 
-func (us *UserService) GetLearnByUsIdAndLimit(ctx context.Context, getWordsReq *requests.GetWordsByUsIdAndLimitRequest) ([]*responses.WordResp, error) {
-	quantity, err := strconv.Atoi(getWordsReq.Limit)
-	if err != nil {
-		appErr := apperrors.GetWordsByUsIdAndLimitServiceErr.AppendMessage(err)
-		us.log.Error(appErr)
-		return nil, appErr
+// wordsFilter asks the repo for one word more than the limit, the extra word tells that there is a next page.
+func (us *UserService) wordsFilter(ctx context.Context, getWordsReq *requests.GetWordsByUsIdAndLimitRequest) (*uuid.UUID, *repositories.WordsFilter, error) {
+	limit := getWordsReq.Limit
+	if limit == 0 {
+		limit = wordsPageLimit
 	}
 
 	userId, err := uuid.Parse(getWordsReq.ID)
 	if err != nil {
-		appErr := apperrors.GetWordsByUsIdAndLimitServiceErr.AppendMessage(err)
-		us.log.Error(appErr)
-		return nil, appErr
+		return nil, nil, apperrors.GetWordsByUsIdAndLimitServiceErr.AppendMessage(err)
 	}
 
-	words, err := us.repoUser.GetLearnByIDAndLimit(ctx, &userId, quantity)
+	from, to, err := chosenLanguagePair(ctx, us.repoUser, &userId, getWordsReq.From, getWordsReq.To)
 	if err != nil {
-		us.log.Error(err)
-		return nil, err
+		return nil, nil, err
+	}
+
+	filter := &repositories.WordsFilter{
+		From:          from,
+		To:            to,
+		Theme:         getWordsReq.Theme,
+		PartsOfSpeech: getWordsReq.PartsOfSpeech,
+		Search:        strings.TrimSpace(getWordsReq.Search),
+		Limit:         limit + 1,
 	}
 
-	wordsResp := mappers.MapWordsToWordsResp(words)
-	return wordsResp, nil
+	if getWordsReq.Cursor != "" {
+		filter.AfterCreatedAt, filter.AfterID, err = decodeWordsCursor(getWordsReq.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return &userId, filter, nil
+}
+
+func (us *UserService) wordsPage(words []*models.Word, total int64, limit int) *responses.WordsPageResp {
+	nextCursor := ""
+	if len(words) > limit {
+		words = words[:limit]
+		nextCursor = encodeWordsCursor(words[limit-1])
+	}
+
+	return &responses.WordsPageResp{Words: mappers.MapWordsToWordsResp(words), Total: total, NextCursor: nextCursor}
 }
 
 func (us *UserService) GetUserById(ctx context.Context, id string) (*models.User, error) {
 	userId, err := uuid.Parse(id)
 	if err != nil {
-		appErr := apperrors.GetUserByIdErr.AppendMessage(err)
+		appErr := apperrors.GetUserByIdErr.AppendMessage(apperrors.BadRequestErr.AppendMessage(err))
 		us.log.Error(appErr)
-		return nil, err
+		return nil, appErr
 	}
 	user, err := us.repoUser.GetUserById(ctx, &userId)
 	if err != nil {
@@ -145,9 +165,16 @@ func (us *UserService) GetUserById(ctx context.Context, id string) (*models.User
 		return nil, err
 	}
 
+	if user == nil || user.ID == nil {
+		appErr := apperrors.GetUserByIdErr.AppendMessage(&apperrors.UserNotFoundErr)
+		us.log.Error(appErr)
+		return nil, appErr
+	}
+
 	return user, nil
 }
 
+// MoveWordToLearned learns the word and schedules its first review in one transaction, the way a batch does it.
 func (us *UserService) MoveWordToLearned(ctx context.Context, deleteWordReq *requests.DeleteWordFromUserByIDRequest) error {
 	userId, err := uuid.Parse(deleteWordReq.UserID)
 	if err != nil {
@@ -156,7 +183,6 @@ func (us *UserService) MoveWordToLearned(ctx context.Context, deleteWordReq *req
 		return err
 	}
 
-	user := &models.User{ID: &userId}
 	wordId, err := uuid.Parse(deleteWordReq.WordID)
 	if err != nil {
 		appErr := apperrors.MoveWordToLearnedErr.AppendMessage(err)
@@ -164,14 +190,23 @@ func (us *UserService) MoveWordToLearned(ctx context.Context, deleteWordReq *req
 		return err
 	}
 
-	word := &models.Word{ID: &wordId}
-
-	err = us.repoUser.MoveWordToLearned(ctx, user, word)
+	op := &models.WordOperation{WordID: &wordId, Action: models.WordActionMoveToLearned}
+	now := time.Now()
+	err = us.repoUser.ApplyWordOperations(ctx, &models.User{ID: &userId}, []*models.WordOperation{op}, func(review *models.Review) {
+		startReview(review, now)
+	})
 	if err != nil {
 		us.log.Error(err)
 		return err
 	}
 
+	if op.Status != models.WordOperationApplied {
+		appErr := apperrors.MoveWordToLearnedErr.AppendMessage(apperrors.WordNotFoundErr.AppendMessage(deleteWordReq.WordID))
+		us.log.Error(appErr)
+		return appErr
+	}
+
+	countWordOperation(op)
 	return nil
 }
 
@@ -199,6 +234,7 @@ func (us *UserService) AddWordToLearn(ctx context.Context, deleteWordReq *reques
 		return err
 	}
 
+	metrics.WordsAddedToLearn.Inc()
 	return nil
 }
 
@@ -228,3 +264,114 @@ func (us *UserService) DeleteLearnFromUserById(ctx context.Context, deleteWordRe
 
 	return nil
 }
+
+// ApplyWordOperations applies the word list changes of a batch in one transaction and reports every operation.
+func (us *UserService) ApplyWordOperations(ctx context.Context, batchReq *requests.WordsBatchRequest) (*responses.WordsBatchResp, error) {
+	userId, err := uuid.Parse(batchReq.UserID)
+	if err != nil {
+		appErr := apperrors.WordsBatchErr.AppendMessage(err)
+		us.log.Error(appErr)
+		return nil, appErr
+	}
+
+	ops := make([]*models.WordOperation, 0, len(batchReq.Operations))
+	for _, opReq := range batchReq.Operations {
+		wordId, err := uuid.Parse(opReq.WordID)
+		if err != nil {
+			appErr := apperrors.WordsBatchErr.AppendMessage(err)
+			us.log.Error(appErr)
+			return nil, appErr
+		}
+
+		ops = append(ops, &models.WordOperation{WordID: &wordId, Action: opReq.Action})
+	}
+
+	now := time.Now()
+	err = us.repoUser.ApplyWordOperations(ctx, &models.User{ID: &userId}, ops, func(review *models.Review) {
+		startReview(review, now)
+	})
+	if err != nil {
+		us.log.Error(err)
+		return nil, err
+	}
+
+	for _, op := range ops {
+		countWordOperation(op)
+	}
+
+	return mappers.MapWordOperationsToWordsBatchResp(ops), nil
+}
+
+// ChangeUserRole lets an admin promote or demote another user, admins can't change their own role.
+func (us *UserService) ChangeUserRole(ctx context.Context, actorID string, userID string, roleReq *requests.ChangeUserRoleRequest) error {
+	if roleReq.Role != models.RoleUser && roleReq.Role != models.RoleTeacher && roleReq.Role != models.RoleAdmin {
+		appErr := apperrors.ChangeUserRoleErr.AppendMessage(apperrors.BadRequestErr.AppendMessage("unknown role", roleReq.Role))
+		us.log.Error(appErr)
+		return appErr
+	}
+
+	if actorID == userID {
+		appErr := apperrors.ChangeUserRoleErr.AppendMessage(apperrors.ConflictErr.AppendMessage("admin can't change own role"))
+		us.log.Error(appErr)
+		return appErr
+	}
+
+	userId, err := uuid.Parse(userID)
+	if err != nil {
+		appErr := apperrors.ChangeUserRoleErr.AppendMessage(err)
+		us.log.Error(appErr)
+		return appErr
+	}
+
+	err = us.repoUser.UpdateUserRole(ctx, &userId, roleReq.Role)
+	if err != nil {
+		us.log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// AddLanguagePair keeps the pair as the one the user has chosen and adds the translations of the pair
+// missing from its shared word list, a translation added to the dictionary later comes with the next call.
+func (us *UserService) AddLanguagePair(ctx context.Context, pairReq *requests.AddLanguagePairRequest) (*responses.AddLanguagePairResponse, error) {
+	if !models.IsLanguage(pairReq.From) || !models.IsLanguage(pairReq.To) || pairReq.From == pairReq.To {
+		appErr := apperrors.AddLanguagePairErr.AppendMessage("unsupported language pair", pairReq.From, pairReq.To)
+		us.log.Error(appErr)
+		return nil, appErr
+	}
+
+	userId, err := uuid.Parse(pairReq.UserID)
+	if err != nil {
+		appErr := apperrors.AddLanguagePairErr.AppendMessage(err)
+		us.log.Error(appErr)
+		return nil, appErr
+	}
+
+	err = us.repoUser.UpdateUserLanguagePair(ctx, &userId, pairReq.From, pairReq.To)
+	if err != nil {
+		us.log.Error(err)
+		return nil, err
+	}
+
+	us.log.Infof("user %v chose the %v -> %v pair", userId, pairReq.From, pairReq.To)
+
+	translations, err := us.repoLexemes.GetTranslationsByPair(ctx, pairReq.From, pairReq.To)
+	if err != nil {
+		us.log.Error(err)
+		return nil, err
+	}
+
+	words := mappers.MapTranslationsToWords(translations, pairReq.From, pairReq.To)
+	if len(words) == 0 {
+		return &responses.AddLanguagePairResponse{Added: 0}, nil
+	}
+
+	added, err := us.repoUser.CreateWords(ctx, words)
+	if err != nil {
+		us.log.Error(err)
+		return nil, err
+	}
+
+	return &responses.AddLanguagePairResponse{Added: added}, nil
+}